@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/myip"
+	"github.com/cikichen/MyIp/ui"
+	"gopkg.in/yaml.v3"
+)
+
+// API源测试的分组
+const (
+	APIKindProvider = "provider"  // IP信息提供者
+	APIKindPublicIP = "public_ip" // 公网IP源
+)
+
+// 失败原因分类
+const (
+	FailureDNS         = "dns"          // 域名解析失败
+	FailureTLS         = "tls"          // TLS握手或证书错误
+	FailureTimeout     = "timeout"      // 请求超时
+	FailureRateLimited = "rate_limited" // HTTP 429
+	FailureAuth        = "auth"         // HTTP 401/403，密钥无效或缺失
+	FailureHTTP        = "http"         // 其他非200状态码
+	FailureSchema      = "schema"       // 响应无法解析或缺少必要字段
+	FailureConnection  = "connection"   // 连接被拒绝、重置等其他网络错误
+	FailureConfig      = "config"       // 无法构建请求，例如缺少必需的API密钥
+	FailureNoRecord    = "no_record"    // 离线数据库中没有该IP的记录
+	FailureOther       = "other"        // 其他错误，例如离线数据库读取失败
+)
+
+// failureLabels 失败原因的显示名称
+var failureLabels = map[string]string{
+	FailureDNS:         "DNS解析失败",
+	FailureTLS:         "TLS错误",
+	FailureTimeout:     "超时",
+	FailureRateLimited: "请求过多(429)",
+	FailureAuth:        "认证失败",
+	FailureHTTP:        "HTTP错误",
+	FailureSchema:      "响应格式错误",
+	FailureConnection:  "连接失败",
+	FailureConfig:      "配置错误",
+	FailureNoRecord:    "没有记录",
+	FailureOther:       "其他错误",
+}
+
+// APITestResult 单个API源的测试结果
+type APITestResult struct {
+	Kind       string  `json:"kind" yaml:"kind"` // provider/public_ip
+	Name       string  `json:"name" yaml:"name"`
+	OK         bool    `json:"ok" yaml:"ok"`
+	LatencyMs  float64 `json:"latency_ms" yaml:"latency_ms"`
+	StatusCode int     `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+	IP         string  `json:"ip,omitempty" yaml:"ip,omitempty"`           // 解析出的IP
+	Country    string  `json:"country,omitempty" yaml:"country,omitempty"` // 解析出的国家代码，仅IP信息提供者
+	Reason     string  `json:"reason,omitempty" yaml:"reason,omitempty"`   // 失败原因分类
+	Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// TestAPISource 并发测试所有已注册的IP信息提供者和公网IP源，跳过缺少必需API密钥的提供者
+// 记录延迟，校验响应能否解析为可用的结果，并对失败原因分类
+func TestAPISource() []APITestResult {
+	providers := myip.UsableProviders(Providers())
+	results := make([]APITestResult, len(providers)+len(publicIPSources))
+	client := diagnosticClient(ipInfoTimeout)
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider myip.Provider) {
+			defer wg.Done()
+			results[i] = testProvider(client, provider)
+		}(i, provider)
+	}
+	for i, url := range publicIPSources {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			results[i] = testPublicIPSource(url)
+		}(len(providers)+i, url)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// diagnosticClient 创建测试API源使用的客户端
+// 请求同样按提供者限速，但不读写缓存，也不计入健康状态，以免诊断影响熔断
+func diagnosticClient(timeout time.Duration) *myip.Client {
+	client := newClient(timeout, nil)
+	client.Hooks.CachedInfo = nil
+	client.Hooks.AfterLookup = logLookup
+	return client
+}
+
+// testProvider 使用提供者查询自身IP（本地提供者或不支持自查询时查询8.8.8.8）
+func testProvider(client *myip.Client, provider myip.Provider) APITestResult {
+	result := APITestResult{Kind: APIKindProvider, Name: provider.Name()}
+
+	ip := ""
+	_, local := provider.(myip.LocalProvider)
+	if local || !provider.Capabilities().SelfLookup {
+		ip = "8.8.8.8"
+	}
+
+	start := time.Now()
+	info, err := client.LookupProvider(context.Background(), provider, ip)
+	result.LatencyMs = milliseconds(time.Since(start))
+	if !local {
+		result.StatusCode = responseStatusCode(err)
+	}
+
+	if err == nil {
+		err = validateIPInfo(info)
+		if err != nil {
+			result.Reason = FailureSchema
+		}
+	}
+	if err != nil {
+		if result.Reason == "" {
+			result.Reason = classifyFailure(err)
+		}
+		result.Error = err.Error()
+		return result
+	}
+
+	result.OK = true
+	result.IP = info.IP
+	result.Country = info.CountryCode
+	return result
+}
+
+// responseStatusCode 根据在线提供者的查询结果推断响应状态码
+// 非200状态码由 *myip.StatusError 给出，成功或响应无法解析时为200，没有收到响应时为0
+func responseStatusCode(err error) int {
+	var statusErr *myip.StatusError
+	var parseErr *myip.ParseError
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode
+	case err == nil || errors.As(err, &parseErr):
+		return http.StatusOK
+	}
+	return 0
+}
+
+// validateIPInfo 检查解析结果是否可用：IP合法，且至少包含国家或ASN信息
+func validateIPInfo(info *model.IPInfo) error {
+	if info == nil || net.ParseIP(info.IP) == nil {
+		return errors.New("响应中没有合法的IP地址")
+	}
+	if info.CountryCode == "" && info.Country == "" && info.ASN == "" {
+		return errors.New("响应中缺少国家和ASN信息")
+	}
+	return nil
+}
+
+// testPublicIPSource 测试公网IP源，不限制协议族
+func testPublicIPSource(url string) APITestResult {
+	result := APITestResult{Kind: APIKindPublicIP, Name: url}
+
+	start := time.Now()
+	ip, err := myip.FetchPublicIP(context.Background(), myip.NewPublicIPClient("tcp", publicIPTimeout), url, "tcp")
+	result.LatencyMs = milliseconds(time.Since(start))
+
+	if err != nil {
+		var statusErr *myip.StatusError
+		if errors.As(err, &statusErr) {
+			result.StatusCode = statusErr.StatusCode
+		}
+		result.Reason = classifyFailure(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.OK = true
+	result.IP = ip
+	result.StatusCode = http.StatusOK
+	return result
+}
+
+// classifyFailure 根据错误类型判断失败原因
+func classifyFailure(err error) string {
+	// 收到了200响应但内容无法解析
+	var parseErr *myip.ParseError
+	if errors.As(err, &parseErr) {
+		return FailureSchema
+	}
+
+	var statusErr *myip.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return FailureRateLimited
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return FailureAuth
+		}
+		return FailureHTTP
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureDNS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return FailureTimeout
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalid x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &certInvalid) || errors.As(err, &recordErr) ||
+		strings.Contains(err.Error(), "tls:") {
+		return FailureTLS
+	}
+
+	if errors.Is(err, myip.ErrInvalidPublicIP) {
+		return FailureSchema
+	}
+	if errors.Is(err, myip.ErrAPIKeyRequired) {
+		return FailureConfig
+	}
+	if errors.Is(err, myip.ErrNoRecord) {
+		return FailureNoRecord
+	}
+
+	var opErr *net.OpError
+	var urlErr *url.Error
+	if errors.As(err, &opErr) || errors.As(err, &urlErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return FailureConnection
+	}
+	return FailureOther
+}
+
+// runAPITest 测试所有API源并按指定格式输出结果
+func runAPITest(output string) {
+	if err := checkOutputFormat(output, OutputText, OutputJSON, OutputYAML); err != nil {
+		fmt.Fprintln(os.Stderr, ui.DrawNotice(err.Error(), ui.IconWarning, ui.BgBrightRed))
+		setExitCode(ExitError)
+		return
+	}
+
+	if output == OutputText {
+		fmt.Println(ui.DrawNotice("正在并发测试所有API源的可用性...", ui.IconInfo, ui.BgBrightBlue))
+	}
+	results := TestAPISource()
+
+	if output != OutputText {
+		if err := writeAPITestResults(os.Stdout, output, results); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice("输出结果失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+		}
+		return
+	}
+
+	fmt.Println("")
+	fmt.Println(getAPITestInfo("IP信息提供者测试结果", APIKindProvider, results))
+	fmt.Println(getAPITestInfo("公网IP源测试结果", APIKindPublicIP, results))
+
+	// 显示测试完成消息
+	fmt.Println(ui.DrawNotice("API源测试完成！", ui.IconCheck, ui.BgBrightGreen))
+}
+
+// writeAPITestResults 按json或yaml格式输出测试结果
+func writeAPITestResults(w io.Writer, format string, results []APITestResult) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(results)
+	}
+	return fmt.Errorf("不支持的输出格式: %s", format)
+}
+
+// getAPITestInfo 返回指定分组的测试结果卡片
+func getAPITestInfo(title string, kind string, results []APITestResult) string {
+	var tableContent strings.Builder
+	validCount, total := 0, 0
+
+	tableContent.WriteString(fmt.Sprintf("%s%-28s%s | %s%-8s%s | %s%-8s%s | %s原因%s\n",
+		ui.Bold, "API 源", ui.Reset, ui.Bold, "状态", ui.Reset, ui.Bold, "延迟", ui.Reset, ui.Bold, ui.Reset))
+	tableContent.WriteString(strings.Repeat("─", 64) + "\n")
+
+	for _, result := range results {
+		if result.Kind != kind {
+			continue
+		}
+		total++
+
+		name := strings.TrimPrefix(result.Name, "https://")
+		latency := fmt.Sprintf("%.0fms", result.LatencyMs)
+		if result.OK {
+			validCount++
+			detail := result.IP
+			if result.Country != "" {
+				detail += " (" + result.Country + ")"
+			}
+			tableContent.WriteString(fmt.Sprintf("%-28s | %s%-8s%s | %-8s | %s\n",
+				name, ui.BrightGreen, "可用 "+ui.IconCheck, ui.Reset, latency, detail))
+			continue
+		}
+
+		reason := failureLabels[result.Reason]
+		if result.StatusCode != 0 && result.StatusCode != http.StatusOK {
+			reason = fmt.Sprintf("%s HTTP %d", reason, result.StatusCode)
+		}
+		tableContent.WriteString(fmt.Sprintf("%-28s | %s%-8s%s | %-8s | %s%s%s\n",
+			name, ui.BrightRed, "不可用 "+ui.IconCross, ui.Reset, latency, ui.BrightRed, reason, ui.Reset))
+	}
+
+	tableContent.WriteString(strings.Repeat("─", 64) + "\n")
+	// 添加总结行
+	tableContent.WriteString(fmt.Sprintf(
+		"%s%-28s%s | %s%d/%d%s",
+		ui.Bold, "总计", ui.Reset,
+		ui.BrightYellow+ui.Bold, validCount, total, ui.Reset,
+	))
+
+	return ui.DrawCard(title, ui.IconServer, tableContent.String(), 80, ui.BrightBlue)
+}
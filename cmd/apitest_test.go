@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/myip"
+)
+
+// stubProvider 请求固定URL并按JSON解析响应的提供者
+type stubProvider struct {
+	url string
+}
+
+func (stubProvider) Name() string { return "stub" }
+
+func (p stubProvider) NewRequest(ip string) (*http.Request, error) {
+	return http.NewRequest("GET", p.url, nil)
+}
+
+func (stubProvider) Parse(data []byte) (*model.IPInfo, error) {
+	var info model.IPInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("解析stub响应失败: %v", err)
+	}
+	return &info, nil
+}
+
+func (stubProvider) Capabilities() myip.Capabilities {
+	return myip.Capabilities{SelfLookup: true}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	status := func(code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(code) }
+	}
+
+	cases := []struct {
+		name       string
+		handler    http.HandlerFunc
+		tls        bool          // 使用自签名证书的TLS服务器，客户端不信任该证书
+		timeout    time.Duration // 客户端超时，0表示使用默认值
+		want       string
+		wantStatus int
+	}{
+		{name: "429", handler: status(http.StatusTooManyRequests), want: FailureRateLimited, wantStatus: 429},
+		{name: "403", handler: status(http.StatusForbidden), want: FailureAuth, wantStatus: 403},
+		{name: "401", handler: status(http.StatusUnauthorized), want: FailureAuth, wantStatus: 401},
+		{name: "500", handler: status(http.StatusInternalServerError), want: FailureHTTP, wantStatus: 500},
+		{
+			name:       "响应无法解析",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("<html>bad gateway</html>")) },
+			want:       FailureSchema,
+			wantStatus: 200,
+		},
+		{name: "证书不受信任", handler: status(http.StatusOK), tls: true, want: FailureTLS},
+		{
+			name: "超时",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			timeout: 50 * time.Millisecond,
+			want:    FailureTimeout,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(tc.handler)
+			if tc.tls {
+				// 客户端拒绝证书时服务端会记录握手错误，测试中不需要输出
+				srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+				srv.StartTLS()
+			} else {
+				srv.Start()
+			}
+			defer srv.Close()
+
+			timeout := tc.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			client := &http.Client{Timeout: timeout}
+			provider := stubProvider{url: srv.URL}
+			req, err := provider.NewRequest("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = myip.DoRequest(client, provider, req)
+			if err == nil {
+				t.Fatal("期望请求失败")
+			}
+			if got := classifyFailure(err); got != tc.want {
+				t.Errorf("classifyFailure(%v) = %q, 期望 %q", err, got, tc.want)
+			}
+			if got := responseStatusCode(err); got != tc.wantStatus {
+				t.Errorf("responseStatusCode = %d, 期望 %d", got, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestClassifyFailureLocal(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: 10.0.0.1", myip.ErrNoRecord), FailureNoRecord},
+		{fmt.Errorf("ipgeolocation.io %w", myip.ErrAPIKeyRequired), FailureConfig},
+		{fmt.Errorf("查询离线数据库失败: %w", errors.New("invalid database")), FailureOther},
+		{fmt.Errorf("获取公网IP失败: %w", myip.ErrInvalidPublicIP), FailureSchema},
+	}
+	for _, tc := range cases {
+		if got := classifyFailure(tc.err); got != tc.want {
+			t.Errorf("classifyFailure(%v) = %q, 期望 %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+// TestTestProviderNoHealth 诊断请求不计入健康状态，也不读写缓存
+func TestTestProviderNoHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	result := testProvider(diagnosticClient(time.Second), stubProvider{url: srv.URL})
+	if result.OK || result.Reason != FailureRateLimited || result.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("result = %+v, 期望 rate_limited/429", result)
+	}
+
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+	loadHealthLocked()
+	if entry, ok := providerHealth.entries["stub"]; ok && entry.Failures > 0 {
+		t.Errorf("诊断请求被计入健康状态: %+v", entry)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+)
+
+// cacheOptions IP信息缓存设置
+var cacheOptions = struct {
+	Enabled bool          // 是否启用缓存
+	Refresh bool          // 忽略已有缓存，强制重新查询并更新缓存
+	TTL     time.Duration // 缓存有效期
+}{
+	Enabled: true,
+	TTL:     time.Hour,
+}
+
+// SetCacheOptions 设置IP信息缓存的开关、强制刷新和有效期
+func SetCacheOptions(enabled bool, refresh bool, ttl time.Duration) {
+	cacheOptions.Enabled = enabled
+	cacheOptions.Refresh = refresh
+	cacheOptions.TTL = ttl
+}
+
+// cacheEntry 缓存文件的内容
+type cacheEntry struct {
+	FetchedAt time.Time     `json:"fetched_at"`
+	Info      *model.IPInfo `json:"info"`
+}
+
+// cacheDir 返回缓存目录，Linux下为 $XDG_CACHE_HOME/myip（默认 ~/.cache/myip）
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "myip"), nil
+}
+
+// cachePath 返回指定提供者和IP对应的缓存文件路径
+func cachePath(provider string, ip string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	// IPv6地址中的冒号在部分文件系统上不合法
+	name := strings.NewReplacer(":", "_", "/", "_").Replace(provider + "_" + ip)
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// loadCache 读取缓存，maxAge为0时忽略有效期（用于API源全部失败时返回过期结果）
+func loadCache(provider string, ip string, maxAge time.Duration) (*cacheEntry, bool) {
+	if !cacheOptions.Enabled || ip == "" {
+		return nil, false
+	}
+
+	path, err := cachePath(provider, ip)
+	if err != nil {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Info == nil {
+		return nil, false
+	}
+	if maxAge > 0 && time.Since(entry.FetchedAt) > maxAge {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// freshCache 读取有效期内的缓存，强制刷新或有效期不大于0时不读取
+func freshCache(provider string, ip string) (*cacheEntry, bool) {
+	if cacheOptions.Refresh || cacheOptions.TTL <= 0 {
+		return nil, false
+	}
+	return loadCache(provider, ip, cacheOptions.TTL)
+}
+
+// saveCache 写入缓存，失败时静默忽略
+func saveCache(provider string, ip string, info *model.IPInfo) {
+	if !cacheOptions.Enabled || ip == "" {
+		return
+	}
+
+	path, err := cachePath(provider, ip)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{FetchedAt: time.Now(), Info: info})
+	if err != nil {
+		return
+	}
+	writeFileAtomic(path, data)
+}
+
+// writeFileAtomic 创建所在目录并写入文件
+// 先写同目录下的临时文件再重命名，避免并发读取时读到写了一半的文件
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/myip"
+)
+
+// newClient 创建命令行使用的 myip.Client
+// 通过钩子接入缓存、提供者限速、健康统计和日志，故障转移由 myip.Client 完成
+func newClient(timeout time.Duration, providers []myip.Provider) *myip.Client {
+	return &myip.Client{
+		HTTPClient:      &http.Client{Timeout: timeout},
+		Providers:       providers,
+		PublicIPSources: publicIPSources,
+		Hooks: myip.Hooks{
+			CachedInfo:     cachedInfo,
+			BeforeRequest:  beforeRequest,
+			AfterLookup:    afterLookup,
+			PublicIPResult: publicIPResult,
+		},
+	}
+}
+
+// cachedInfo 返回有效期内的缓存结果
+func cachedInfo(provider myip.Provider, ip string) (*model.IPInfo, bool) {
+	entry, ok := freshCache(provider.Name(), ip)
+	if !ok {
+		return nil, false
+	}
+	debugf("提供者 %s 使用 %s 缓存的结果", provider.Name(), entry.FetchedAt.Format("2006-01-02 15:04:05"))
+	return entry.Info, true
+}
+
+// beforeRequest 按提供者限速，避免批量查询时触发API的频率限制
+func beforeRequest(provider myip.Provider, req *http.Request) {
+	waitProviderRate(provider.Name())
+	debugf("请求 %s %s", req.Method, redactURL(req.URL))
+}
+
+// afterLookup 记录查询结果，在线提供者的结果计入健康状态，成功时写入缓存
+func afterLookup(provider myip.Provider, ip string, info *model.IPInfo, latency time.Duration, err error) {
+	logLookup(provider, ip, info, latency, err)
+
+	// 本地提供者不需要健康统计和缓存
+	if _, ok := provider.(myip.LocalProvider); ok {
+		return
+	}
+	recordProviderResult(provider.Name(), latency, err)
+	if err == nil {
+		saveCache(provider.Name(), ip, info)
+	}
+}
+
+// logLookup 输出提供者的查询结果
+func logLookup(provider myip.Provider, ip string, _ *model.IPInfo, latency time.Duration, err error) {
+	if err != nil {
+		verbosef("提供者 %s 查询 %q 失败 %v: %v", provider.Name(), ip, latency.Round(time.Millisecond), err)
+	} else {
+		verbosef("提供者 %s 查询 %q 成功 %v", provider.Name(), ip, latency.Round(time.Millisecond))
+	}
+}
+
+// publicIPResult 记录每个公网IP源的尝试结果
+func publicIPResult(source string, network string, ip string, latency time.Duration, err error) {
+	latency = latency.Round(time.Millisecond)
+	if err != nil {
+		verbosef("公网IP源 %s (%s) 失败 %v: %v", source, network, latency, err)
+		return
+	}
+	verbosef("公网IP源 %s (%s) 返回 %s %v", source, network, ip, latency)
+}
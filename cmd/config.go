@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cikichen/MyIp/myip"
+	"github.com/cikichen/MyIp/network"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cfgFile 通过 --config 指定的配置文件
+var cfgFile string
+
+// configEnvPrefix 环境变量前缀，配置项中的"."替换为"_"
+// 例如 MYIP_TIMEOUTS_IP_INFO=5s 覆盖 timeouts.ip_info，MYIP_CONFIG 指定配置文件
+const configEnvPrefix = "MYIP"
+
+// SiteConfig 配置文件中的测试站点
+type SiteConfig struct {
+	Name string `mapstructure:"name"`
+	URL  string `mapstructure:"url"`
+}
+
+// ratingLevels 配置文件中的评级键及对应的评级名称
+var ratingLevels = []struct {
+	Key    string
+	Rating string
+}{
+	{"excellent", "优秀"},
+	{"good", "良好"},
+	{"fair", "一般"},
+}
+
+// configCandidates 返回默认的配置文件位置，按优先级排序
+// 依次为 $XDG_CONFIG_HOME/myip/config.{yaml,yml,toml} 和 $HOME/.ip.{yaml,yml,toml}
+func configCandidates() []string {
+	exts := []string{"yaml", "yml", "toml"}
+	var candidates []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		for _, ext := range exts {
+			candidates = append(candidates, filepath.Join(dir, "myip", "config."+ext))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, ext := range exts {
+			candidates = append(candidates, filepath.Join(home, ".ip."+ext))
+		}
+	}
+	return candidates
+}
+
+// initConfig 读取配置文件和环境变量并应用到各项设置
+// 优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+func initConfig(cmd *cobra.Command) error {
+	// 先设置日志级别，以便输出读取配置的过程
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		logLevel = logDebug
+	} else if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		logLevel = logVerbose
+	}
+
+	v := viper.New()
+	v.SetEnvPrefix(configEnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	// 默认值，同时让环境变量可以覆盖这些配置项
+	v.SetDefault("cache.enabled", true)
+	v.SetDefault("cache.ttl", time.Hour)
+
+	// 未指定配置文件时使用第一个存在的默认配置文件
+	path := cfgFile
+	if path == "" {
+		path = v.GetString("config")
+	}
+	if path == "" {
+		for _, candidate := range configCandidates() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path != "" {
+		debugf("使用配置文件 %s", path)
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("读取配置文件 %s 失败: %v", path, err)
+		}
+	}
+
+	// 命令行参数优先于配置文件
+	flags := cmd.Flags()
+	v.BindPFlag("cache.ttl", flags.Lookup("cache-ttl"))
+	v.BindPFlag("geo_db", flags.Lookup("geo-db"))
+	v.BindPFlag("merge", flags.Lookup("merge"))
+	v.BindPFlag("ping.count", flags.Lookup("ping-count"))
+	v.BindPFlag("ping.interval", flags.Lookup("ping-interval"))
+	v.BindPFlag("ping.size", flags.Lookup("ping-size"))
+	v.BindPFlag("ping.port", flags.Lookup("ping-port"))
+
+	// 打开离线数据库，注册为 mmdb 提供者并作为在线API源失败时的备用
+	if geoDB := configStrings(v, "geo_db"); len(geoDB) > 0 {
+		if err := SetGeoDB(geoDB...); err != nil {
+			return err
+		}
+	}
+
+	// 提供者的API密钥，键名为提供者名称的第一段，例如 api_keys.ipinfo 或 MYIP_API_KEYS_IPINFO，
+	// 名称中的"-"替换为"_"以便通过环境变量设置，例如 api_keys.ip_api
+	for _, p := range Providers() {
+		if _, ok := p.(myip.AuthProvider); !ok {
+			continue
+		}
+		key := "api_keys." + providerConfigName(p.Name())
+		if token := v.GetString(key); token != "" {
+			if err := SetProviderAPIKey(p.Name(), token); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 默认的IP信息提供者顺序，需在注册离线数据库之后设置
+	if err := SetDefaultProviders(configStrings(v, "providers")); err != nil {
+		return fmt.Errorf("配置项 providers 无效: %v", err)
+	}
+
+	SetMergeProviders(v.GetBool("merge"))
+
+	// 提供者限速：命令行的 --rate 优先于 rate_limits.default，设置的是默认限速；
+	// 免费额度更严格的提供者保留内置限速，除非通过 rate_limits.<提供者> 单独设置，
+	// 键名规则与 api_keys 相同，例如 rate_limits.ip_api
+	if rate := flags.Lookup("rate"); rate != nil && rate.Changed {
+		perSecond, _ := flags.GetFloat64("rate")
+		SetProviderRateLimit(perSecond)
+	} else if v.IsSet("rate_limits.default") {
+		SetProviderRateLimit(v.GetFloat64("rate_limits.default"))
+	}
+	for _, p := range Providers() {
+		if key := "rate_limits." + providerConfigName(p.Name()); v.IsSet(key) {
+			SetProviderRate(p.Name(), v.GetFloat64(key))
+		}
+	}
+
+	if v.IsSet("public_ip_sources") {
+		if sources := configStrings(v, "public_ip_sources"); len(sources) > 0 {
+			publicIPSources = sources
+		}
+	}
+
+	// 设置IP信息缓存
+	enabled := v.GetBool("cache.enabled")
+	if noCache, _ := flags.GetBool("no-cache"); flags.Changed("no-cache") {
+		enabled = !noCache
+	}
+	refresh, _ := flags.GetBool("refresh")
+	SetCacheOptions(enabled, refresh, v.GetDuration("cache.ttl"))
+
+	// 请求超时时间，nettest 的 --timeout 参数会再次覆盖站点测试超时
+	if v.IsSet("timeouts.public_ip") {
+		publicIPTimeout = v.GetDuration("timeouts.public_ip")
+	}
+	if v.IsSet("timeouts.ip_info") {
+		ipInfoTimeout = v.GetDuration("timeouts.ip_info")
+	}
+	if v.IsSet("timeouts.site") {
+		network.SetGlobalTimeout(v.GetDuration("timeouts.site"))
+	}
+
+	// Ping的包数、间隔和负载大小，未设置的项使用默认值
+	network.SetPingOptions(network.PingOptions{
+		Count:    v.GetInt("ping.count"),
+		Interval: v.GetDuration("ping.interval"),
+		Size:     v.GetInt("ping.size"),
+		Port:     v.GetInt("ping.port"),
+	})
+
+	if err := applySitesConfig(v); err != nil {
+		return err
+	}
+	applyRatingConfig(v)
+	return nil
+}
+
+// providerConfigName 提供者在配置项中的键名，取名称的第一段并将"-"替换为"_"，以便通过环境变量设置
+func providerConfigName(name string) string {
+	name = strings.SplitN(name, ".", 2)[0]
+	return strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+}
+
+// configStrings 读取字符串列表配置，支持环境变量中以逗号分隔的写法
+func configStrings(v *viper.Viper, key string) []string {
+	var values []string
+	for _, item := range v.GetStringSlice(key) {
+		for _, value := range strings.Split(item, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// applySitesConfig 使用配置文件中的站点列表替换默认的常用站点
+func applySitesConfig(v *viper.Viper) error {
+	var sites []SiteConfig
+	if err := v.UnmarshalKey("sites", &sites); err != nil {
+		return fmt.Errorf("配置项 sites 无效: %v", err)
+	}
+	if len(sites) == 0 {
+		return nil
+	}
+
+	commonSites := make([]network.Site, 0, len(sites))
+	for i, site := range sites {
+		if site.URL == "" {
+			return fmt.Errorf("配置项 sites 第 %d 项缺少 url", i+1)
+		}
+		if !strings.HasPrefix(site.URL, "http://") && !strings.HasPrefix(site.URL, "https://") {
+			site.URL = "https://" + site.URL
+		}
+		if site.Name == "" {
+			site.Name = site.URL
+		}
+		commonSites = append(commonSites, network.Site{Name: site.Name, URL: site.URL})
+	}
+	network.CommonSites = commonSites
+	return nil
+}
+
+// applyRatingConfig 使用配置文件中的评级阈值覆盖默认值，未配置的项保持默认
+func applyRatingConfig(v *viper.Viper) {
+	for _, level := range ratingLevels {
+		for i := range network.RatingThresholds {
+			t := &network.RatingThresholds[i]
+			if t.Rating != level.Rating {
+				continue
+			}
+			prefix := "rating." + level.Key + "."
+			if v.IsSet(prefix + "access_rate") {
+				t.MinAccessRate = v.GetFloat64(prefix + "access_rate")
+			}
+			if v.IsSet(prefix + "response_time") {
+				t.MaxResponseTime = v.GetDuration(prefix + "response_time")
+			}
+			if v.IsSet(prefix + "ping_time") {
+				t.MaxPingTime = v.GetDuration(prefix + "ping_time")
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cikichen/MyIp/myip"
+	"github.com/cikichen/MyIp/ui"
+)
+
+// SourceResult 单个公网IP源的查询结果
+type SourceResult struct {
+	Source string `json:"source" yaml:"source"`
+	IP     string `json:"ip,omitempty" yaml:"ip,omitempty"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+	err    error  // 原始错误，用于判断失败原因
+}
+
+// PublicIPConsensus 多源共识查询公网IP的结果
+type PublicIPConsensus struct {
+	Network      string              `json:"network" yaml:"network"`           // 查询使用的网络(tcp4/tcp6/tcp)
+	IP           string              `json:"ip" yaml:"ip"`                     // 多数源一致的IP，全部失败时为空
+	Agreed       []string            `json:"agreed" yaml:"agreed"`             // 返回该IP的源
+	Disagreement bool                `json:"disagreement" yaml:"disagreement"` // 有效结果中是否存在不同的IP
+	Candidates   map[string][]string `json:"candidates" yaml:"candidates"`     // 每个IP及返回它的源
+	Results      []SourceResult      `json:"results" yaml:"results"`           // 每个源的查询结果（按源优先级排序）
+}
+
+// GetMyPublicIPConsensus 通过指定网络并发查询所有公网IP源，返回多数源一致的IP
+// 票数相同时按源的优先级选择，用于发现分流VPN或多WAN导致的出口不一致
+func GetMyPublicIPConsensus(network string) *PublicIPConsensus {
+	client := myip.NewPublicIPClient(network, publicIPTimeout)
+
+	// 并发查询所有API源，结果按源的顺序存放
+	results := make([]SourceResult, len(publicIPSources))
+	var wg sync.WaitGroup
+	for i, url := range publicIPSources {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			results[i].Source = url
+			ip, err := myip.FetchPublicIP(context.Background(), client, url, network)
+			if err != nil {
+				verbosef("公网IP源 %s (%s) 失败: %v", url, network, err)
+				results[i].Error = err.Error()
+				results[i].err = err
+				return
+			}
+			verbosef("公网IP源 %s (%s) 返回 %s", url, network, ip)
+			results[i].IP = ip
+		}(i, url)
+	}
+	wg.Wait()
+
+	consensus := buildConsensus(results)
+	consensus.Network = network
+	return consensus
+}
+
+// Err 所有源都失败时返回各源的失败原因
+func (c *PublicIPConsensus) Err() error {
+	if c.IP != "" {
+		return nil
+	}
+	failed := &myip.AggregateError{Op: "获取公网IP(" + c.Network + ")"}
+	for _, result := range c.Results {
+		if result.err != nil {
+			failed.Errors = append(failed.Errors, &myip.SourceError{Source: result.Source, Err: result.err})
+		}
+	}
+	return failed
+}
+
+// buildConsensus 根据各源的查询结果统计票数并选出多数IP
+func buildConsensus(results []SourceResult) *PublicIPConsensus {
+	consensus := &PublicIPConsensus{
+		Candidates: make(map[string][]string),
+		Results:    results,
+	}
+
+	// 记录每个IP第一次出现的位置，用于票数相同时按优先级选择
+	firstSeen := make(map[string]int)
+	for i, result := range results {
+		if result.IP == "" {
+			continue
+		}
+		if _, ok := firstSeen[result.IP]; !ok {
+			firstSeen[result.IP] = i
+		}
+		consensus.Candidates[result.IP] = append(consensus.Candidates[result.IP], result.Source)
+	}
+
+	if len(consensus.Candidates) == 0 {
+		return consensus
+	}
+
+	ips := make([]string, 0, len(consensus.Candidates))
+	for ip := range consensus.Candidates {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		vi, vj := len(consensus.Candidates[ips[i]]), len(consensus.Candidates[ips[j]])
+		if vi != vj {
+			return vi > vj
+		}
+		return firstSeen[ips[i]] < firstSeen[ips[j]]
+	})
+
+	consensus.IP = ips[0]
+	consensus.Agreed = consensus.Candidates[ips[0]]
+	consensus.Disagreement = len(ips) > 1
+	return consensus
+}
+
+// getConsensusInfo 返回多源共识结果卡片
+func getConsensusInfo(consensus *PublicIPConsensus) string {
+	var sb strings.Builder
+
+	for _, result := range consensus.Results {
+		status := ui.BrightGreen + result.IP + ui.Reset
+		if result.IP == "" {
+			status = ui.BrightRed + "失败 - " + result.Error + ui.Reset
+		} else if result.IP != consensus.IP {
+			status = ui.BrightYellow + result.IP + " " + ui.IconWarning + ui.Reset
+		}
+		sb.WriteString(fmt.Sprintf("%s%s:%s %s\n", ui.Bold, result.Source, ui.Reset, status))
+	}
+
+	cardColor := ui.BrightGreen
+	switch {
+	case consensus.IP == "":
+		cardColor = ui.BrightRed
+		sb.WriteString(fmt.Sprintf("%s结论:%s 所有源都失败", ui.Bold, ui.Reset))
+	case consensus.Disagreement:
+		cardColor = ui.BrightYellow
+		sb.WriteString(fmt.Sprintf("%s结论:%s %s各源结果不一致%s，采用多数结果 %s (%d/%d)",
+			ui.Bold, ui.Reset, ui.BrightYellow, ui.Reset, consensus.IP, len(consensus.Agreed), len(consensus.Results)))
+	default:
+		sb.WriteString(fmt.Sprintf("%s结论:%s 各源结果一致 (%d/%d)",
+			ui.Bold, ui.Reset, len(consensus.Agreed), len(consensus.Results)))
+	}
+
+	title := "公网IP多源校验"
+	if family := networkFamily(consensus.Network); family != "" {
+		title += " (" + family + ")"
+	}
+	return ui.DrawCard(title, ui.IconNetwork, sb.String(), 60, cardColor)
+}
+
+// networkFamily 返回网络对应的协议族名称
+func networkFamily(network string) string {
+	switch network {
+	case "tcp4":
+		return "IPv4"
+	case "tcp6":
+		return "IPv6"
+	}
+	return ""
+}
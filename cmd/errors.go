@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cikichen/MyIp/myip"
+)
+
+// 退出码，用于脚本区分失败的原因
+const (
+	ExitOK              = 0
+	ExitError           = 1 // 参数、配置或输出错误
+	ExitNoNetwork       = 2 // 网络不可用：无法获取本地地址，或所有请求都因网络不可达失败
+	ExitProvidersFailed = 3 // 网络可用，但所有公网IP源或IP信息提供者都失败
+	ExitPartial         = 4 // 只获取到部分数据：结果来自离线数据库或过期缓存，或批量查询中部分目标失败
+)
+
+// exitCode 命令执行结束后的退出码
+var exitCode = ExitOK
+
+// setExitCode 设置退出码，已设置时保留更严重（数值更小）的退出码
+func setExitCode(code int) {
+	if code != ExitOK && (exitCode == ExitOK || code < exitCode) {
+		exitCode = code
+	}
+}
+
+// PartialError 所有在线提供者都失败，结果来自离线数据库或过期缓存
+type PartialError struct {
+	Source string // 结果的来源，例如离线数据库或缓存时间
+	Err    error  // 在线提供者的失败原因
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%v\n已使用%s的结果", e.Err, e.Source)
+}
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// exitCodeFor 根据错误类型返回对应的退出码
+func exitCodeFor(err error) int {
+	var partial *PartialError
+	var aggregate *myip.AggregateError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &partial):
+		return ExitPartial
+	case errors.Is(err, myip.ErrNoNetwork):
+		return ExitNoNetwork
+	case errors.As(err, &aggregate):
+		return ExitProvidersFailed
+	}
+	return ExitError
+}
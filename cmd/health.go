@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cikichen/MyIp/myip"
+)
+
+const (
+	circuitFailureThreshold = 3               // 连续失败多少次后熔断
+	circuitOpenDuration     = 5 * time.Minute // 熔断持续时间，HTTP 429 未指定 Retry-After 时也使用该值
+	latencySmoothing        = 0.3             // 平均延迟的指数平滑系数，越大越偏重最近的请求
+)
+
+// 熔断器状态
+const (
+	CircuitClosed   = "closed"    // 正常
+	CircuitOpen     = "open"      // 熔断中，跳过该提供者
+	CircuitHalfOpen = "half-open" // 熔断已到期，同一时间只放行一个请求探测是否恢复
+)
+
+// ProviderHealth 提供者的健康状态，在多次运行之间持久化
+type ProviderHealth struct {
+	Name                string    `json:"name" yaml:"name"`
+	Successes           int       `json:"successes" yaml:"successes"`
+	Failures            int       `json:"failures" yaml:"failures"`
+	ConsecutiveFailures int       `json:"consecutive_failures" yaml:"consecutive_failures"`
+	AvgLatencyMs        float64   `json:"avg_latency_ms" yaml:"avg_latency_ms"` // 成功请求延迟的指数移动平均
+	LastError           string    `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+	LastSuccess         time.Time `json:"last_success" yaml:"last_success,omitempty"`
+	LastFailure         time.Time `json:"last_failure" yaml:"last_failure,omitempty"`
+	OpenUntil           time.Time `json:"open_until" yaml:"open_until,omitempty"` // 熔断截止时间
+	State               string    `json:"state,omitempty" yaml:"state,omitempty"` // 查询状态时计算，不持久化
+	probing             bool      // 半开状态下已有查询占用了探测机会，不持久化
+}
+
+// SuccessRate 返回成功率 (0-1)，没有请求记录时返回0
+func (h *ProviderHealth) SuccessRate() float64 {
+	total := h.Successes + h.Failures
+	if total == 0 {
+		return 0
+	}
+	return float64(h.Successes) / float64(total)
+}
+
+// CircuitState 返回当前的熔断器状态
+func (h *ProviderHealth) CircuitState(now time.Time) string {
+	switch {
+	case h.OpenUntil.IsZero():
+		return CircuitClosed
+	case now.Before(h.OpenUntil):
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// weight 负载均衡权重：平滑后的成功率除以延迟因子，没有记录的提供者权重为0.5
+func (h *ProviderHealth) weight() float64 {
+	rate := float64(h.Successes+1) / float64(h.Successes+h.Failures+2)
+	return rate / (1 + h.AvgLatencyMs/1000)
+}
+
+// providerHealth 所有提供者的健康状态，首次使用时从磁盘读取
+var providerHealth = struct {
+	sync.Mutex
+	loaded  bool
+	dirty   bool
+	entries map[string]*ProviderHealth
+	rand    *rand.Rand
+}{}
+
+// healthPath 返回健康状态文件的路径，与IP信息缓存位于同一目录
+func healthPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "health.json"), nil
+}
+
+// loadHealthLocked 读取健康状态文件，文件不存在或损坏时从空状态开始，调用方需持有锁
+func loadHealthLocked() {
+	if providerHealth.loaded {
+		return
+	}
+	providerHealth.loaded = true
+	providerHealth.entries = make(map[string]*ProviderHealth)
+	providerHealth.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	path, err := healthPath()
+	if err != nil {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var entries []*ProviderHealth
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		providerHealth.entries[entry.Name] = entry
+	}
+}
+
+// healthEntryLocked 返回指定提供者的健康状态，不存在时创建，调用方需持有锁
+func healthEntryLocked(name string) *ProviderHealth {
+	loadHealthLocked()
+	entry, ok := providerHealth.entries[name]
+	if !ok {
+		entry = &ProviderHealth{Name: name}
+		providerHealth.entries[name] = entry
+	}
+	return entry
+}
+
+// recordProviderResult 记录一次在线请求的结果
+// 连续失败达到阈值或返回HTTP 429时熔断该提供者，成功后恢复；
+// 缺少API密钥时没有发出请求，不计入健康状态
+func recordProviderResult(name string, latency time.Duration, err error) {
+	if errors.Is(err, myip.ErrAPIKeyRequired) {
+		return
+	}
+
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	entry := healthEntryLocked(name)
+	providerHealth.dirty = true
+	now := time.Now()
+
+	entry.probing = false
+	if err == nil {
+		entry.Successes++
+		entry.ConsecutiveFailures = 0
+		entry.LastSuccess = now
+		entry.OpenUntil = time.Time{}
+
+		ms := float64(latency) / float64(time.Millisecond)
+		if entry.AvgLatencyMs == 0 {
+			entry.AvgLatencyMs = ms
+		} else {
+			entry.AvgLatencyMs = latencySmoothing*ms + (1-latencySmoothing)*entry.AvgLatencyMs
+		}
+		return
+	}
+
+	entry.Failures++
+	entry.ConsecutiveFailures++
+	entry.LastFailure = now
+	entry.LastError = err.Error()
+
+	var statusErr *myip.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+		wait := statusErr.RetryAfter
+		if wait <= 0 {
+			wait = circuitOpenDuration
+		}
+		entry.OpenUntil = now.Add(wait)
+	} else if entry.ConsecutiveFailures >= circuitFailureThreshold {
+		entry.OpenUntil = now.Add(circuitOpenDuration)
+	}
+}
+
+// availableProviders 过滤掉处于熔断状态的提供者，全部熔断时原样返回以免无源可用
+// 熔断到期（半开）的提供者同一时间只分配给一次查询用于探测，其他查询在探测结束前跳过它；
+// 返回本次占用了探测机会的提供者名称，调用方查询结束后需调用 releaseProbes 释放
+func availableProviders(providers []myip.Provider) ([]myip.Provider, []string) {
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	now := time.Now()
+	available := make([]myip.Provider, 0, len(providers))
+	var probes []string
+	for _, p := range providers {
+		// 本地提供者没有熔断状态，只有通过 --provider 或配置显式指定时才会出现在这里
+		if _, ok := p.(myip.LocalProvider); ok {
+			available = append(available, p)
+			continue
+		}
+		entry := healthEntryLocked(p.Name())
+		switch entry.CircuitState(now) {
+		case CircuitOpen:
+			continue
+		case CircuitHalfOpen:
+			if entry.probing {
+				continue
+			}
+			entry.probing = true
+			probes = append(probes, p.Name())
+		}
+		available = append(available, p)
+	}
+	if len(available) == 0 {
+		return providers, probes
+	}
+	return available, probes
+}
+
+// releaseProbes 释放 availableProviders 占用的探测机会
+// 探测请求已完成时 recordProviderResult 已经释放；故障转移提前成功而没有请求该提供者时在这里释放
+func releaseProbes(names []string) {
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	for _, name := range names {
+		healthEntryLocked(name).probing = false
+	}
+}
+
+// balanceProviders 按健康状态加权随机排序，成功率高、延迟低的提供者更可能排在前面
+// 使用 Efraimidis-Spirakis 加权随机抽样，每个提供者的排序键为 u^(1/w)。
+// 本地提供者没有健康记录，会被移除，由调用方作为备用；缺少必需API密钥的提供者也会被移除
+func balanceProviders(providers []myip.Provider) []myip.Provider {
+	providers = onlineProviders(providers)
+
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	keys := make(map[string]float64, len(providers))
+	for _, p := range providers {
+		w := healthEntryLocked(p.Name()).weight()
+		keys[p.Name()] = math.Pow(providerHealth.rand.Float64(), 1/w)
+	}
+
+	ordered := append([]myip.Provider(nil), providers...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return keys[ordered[i].Name()] > keys[ordered[j].Name()]
+	})
+	return ordered
+}
+
+// ProviderHealthStatus 返回所有已注册在线提供者的健康状态（按名称排序）
+func ProviderHealthStatus() []ProviderHealth {
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	now := time.Now()
+	var status []ProviderHealth
+	for _, p := range Providers() {
+		if _, ok := p.(myip.LocalProvider); ok {
+			continue
+		}
+		entry := *healthEntryLocked(p.Name())
+		entry.State = entry.CircuitState(now)
+		status = append(status, entry)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Name < status[j].Name })
+	return status
+}
+
+// saveProviderHealth 有新的请求记录时写入健康状态文件
+func saveProviderHealth() error {
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	if !providerHealth.dirty {
+		return nil
+	}
+
+	path, err := healthPath()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*ProviderHealth, 0, len(providerHealth.entries))
+	for _, entry := range providerHealth.entries {
+		if entry.Successes+entry.Failures > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	providerHealth.dirty = false
+	return nil
+}
+
+// ResetProviderHealth 清除所有提供者的健康状态
+func ResetProviderHealth() error {
+	providerHealth.Lock()
+	defer providerHealth.Unlock()
+
+	loadHealthLocked()
+	providerHealth.entries = make(map[string]*ProviderHealth)
+	providerHealth.dirty = false
+
+	path, err := healthPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cikichen/MyIp/myip"
+)
+
+// TestHalfOpenSingleProbe 熔断到期后同一时间只放行一次探测
+func TestHalfOpenSingleProbe(t *testing.T) {
+	provider := namedProvider{stubProvider{}, "half-open-test"}
+	providerHealth.Lock()
+	entry := healthEntryLocked(provider.Name())
+	entry.ConsecutiveFailures = circuitFailureThreshold
+	entry.OpenUntil = time.Now().Add(-time.Second)
+	providerHealth.Unlock()
+
+	// 加入一个健康的提供者，避免全部不可用时原样返回
+	list := []myip.Provider{provider, namedProvider{stubProvider{}, "healthy"}}
+
+	first, probes := availableProviders(list)
+	if len(first) != 2 || len(probes) != 1 {
+		t.Fatalf("第一次查询 available=%d probes=%v, 期望放行探测", len(first), probes)
+	}
+	second, _ := availableProviders(list)
+	if len(second) != 1 || second[0].Name() != "healthy" {
+		t.Fatalf("探测进行中时第二次查询 available=%v, 期望跳过半开的提供者", names(second))
+	}
+
+	// 探测失败后重新熔断
+	recordProviderResult(provider.Name(), 0, errors.New("probe failed"))
+	if available, _ := availableProviders(list); len(available) != 1 {
+		t.Fatalf("探测失败后 available=%v, 期望仍然熔断", names(available))
+	}
+
+	// 没有实际请求的探测机会在查询结束后释放
+	providerHealth.Lock()
+	entry.OpenUntil = time.Now().Add(-time.Second)
+	providerHealth.Unlock()
+	_, probes = availableProviders(list)
+	releaseProbes(probes)
+	if available, _ := availableProviders(list); len(available) != 2 {
+		t.Fatalf("释放后 available=%v, 期望再次放行探测", names(available))
+	}
+}
+
+// namedProvider 使用指定名称的提供者
+type namedProvider struct {
+	myip.Provider
+	name string
+}
+
+func (p namedProvider) Name() string { return p.name }
+
+func names(providers []myip.Provider) []string {
+	var list []string
+	for _, p := range providers {
+		list = append(list, p.Name())
+	}
+	return list
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cikichen/MyIp/network"
+	"github.com/cikichen/MyIp/ui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// interfacesCmd 列出所有本地网络接口
+var interfacesCmd = &cobra.Command{
+	Use:   "interfaces",
+	Short: "列出所有本地网络接口及地址",
+	Long: `列出所有本地网络接口，包括标志、MTU、MAC地址、全部IPv4/IPv6地址及前缀长度，
+并标记持有默认路由的接口（Linux下解析 /proc/net/route 和 /proc/net/ipv6_route）。
+例如:
+  ip interfaces
+  ip interfaces --output json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		if err := checkOutputFormat(output, OutputText, OutputJSON, OutputYAML); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice(err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+
+		ifaces, err := network.ListInterfaces()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice("无法获取网络接口: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+
+		if err := writeInterfaces(os.Stdout, output, ifaces); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice("输出结果失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+		}
+	},
+}
+
+// writeInterfaces 按指定格式输出网络接口列表
+func writeInterfaces(w io.Writer, format string, ifaces []network.InterfaceInfo) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(ifaces)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(ifaces)
+	}
+	_, err := fmt.Fprintln(w, ui.RenderInterfacesWithLipgloss(ifaces))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(interfacesCmd)
+
+	interfacesCmd.Flags().StringP("output", "o", OutputText, "输出格式: text, json, yaml")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strings"
+)
+
+// 日志级别，由 --verbose 和 --debug 设置
+const (
+	logQuiet   = iota // 只输出警告
+	logVerbose        // 输出每个公网IP源和提供者的尝试结果
+	logDebug          // 额外输出请求URL、缓存命中、熔断跳过等细节
+)
+
+var logLevel = logQuiet
+
+// verbosef 在 --verbose 或 --debug 时向标准错误输出一行日志
+func verbosef(format string, args ...interface{}) {
+	if logLevel >= logVerbose {
+		fmt.Fprintf(os.Stderr, "[verbose] "+format+"\n", args...)
+	}
+}
+
+// debugf 在 --debug 时向标准错误输出一行日志
+func debugf(format string, args ...interface{}) {
+	if logLevel >= logDebug {
+		fmt.Fprintf(os.Stderr, "[debug] "+format+"\n", args...)
+	}
+}
+
+// redactURL 隐藏URL查询参数中的API密钥，用于输出调试日志
+func redactURL(u *neturl.URL) string {
+	query := u.Query()
+	for name := range query {
+		switch strings.ToLower(name) {
+		case "key", "apikey", "api_key", "token":
+			query.Set(name, "***")
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
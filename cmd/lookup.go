@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/myip"
+	"github.com/cikichen/MyIp/ui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// lookupTableThreshold 查询结果超过该数量时使用表格显示
+const lookupTableThreshold = 3
+
+// LookupResult 查询单个地址的结果
+type LookupResult struct {
+	Target string        `json:"target" yaml:"target"` // 用户输入的IP或域名
+	IP     string        `json:"ip" yaml:"ip"`         // 实际查询的IP
+	Info   *model.IPInfo `json:"info" yaml:"info"`
+	Error  string        `json:"error,omitempty" yaml:"error,omitempty"`
+	// Failures 每个提供者的失败原因，结果来自离线数据库或过期缓存时也会列出
+	Failures []string `json:"failures,omitempty" yaml:"failures,omitempty"`
+	err      error    // 原始错误，用于确定退出码
+}
+
+// lookupCmd 查询任意IP或域名的详细信息
+var lookupCmd = &cobra.Command{
+	Use:   "lookup <IP|域名>...",
+	Short: "查询指定IP或域名的详细信息",
+	Long: `查询指定IP或域名的地理位置、网络信息、IP类型和纯净度。
+域名会被解析为所有IPv4/IPv6地址并逐一查询；结果较多时以表格显示。
+也可以通过 --file 从文件或标准输入批量读取目标，重复的目标只查询一次。
+例如:
+  ip lookup 8.8.8.8
+  ip lookup 8.8.8.8 example.com 2001:db8::1
+  ip lookup example.com --output json
+  ip lookup --file ips.txt --output csv
+  cat ips.txt | ip lookup --file - --workers 16 --rate 2 --output ndjson`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		if err := checkOutputFormat(output, OutputText, OutputJSON, OutputYAML, OutputCSV, OutputNDJSON); err != nil {
+			fmt.Println(ui.DrawNotice(err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+		machine := output != OutputText
+
+		providerNames, _ := cmd.Flags().GetStringSlice("provider")
+		providers, err := SelectProviders(providerNames)
+		if err != nil {
+			fmt.Println(ui.DrawNotice(err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+
+		// 合并命令行参数和文件中的目标
+		targets := args
+		file, _ := cmd.Flags().GetString("file")
+		if file != "" {
+			fileTargets, err := readTargets(file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.DrawNotice("读取目标列表失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+				setExitCode(ExitError)
+				return
+			}
+			targets = append(targets, fileTargets...)
+		}
+		if len(targets) == 0 {
+			fmt.Println(ui.DrawNotice("请指定要查询的IP或域名，或使用 --file 从文件读取", ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+
+		// 每个提供者的限速由 --rate 或配置文件在 initConfig 中设置
+		workers, _ := cmd.Flags().GetInt("workers")
+
+		if output == OutputNDJSON {
+			// ndjson 每完成一个查询就输出一行，不等待所有目标查询完成
+			var results []LookupResult
+			encoder := json.NewEncoder(os.Stdout)
+			var writeErr error
+			lookupTargets(targets, providers, workers, func(_ int, result LookupResult) {
+				results = append(results, result)
+				if writeErr == nil {
+					writeErr = encoder.Encode(result)
+				}
+			})
+			setExitCode(lookupExitCode(results))
+			if writeErr != nil {
+				fmt.Fprintln(os.Stderr, ui.DrawNotice("输出结果失败: "+writeErr.Error(), ui.IconWarning, ui.BgBrightRed))
+				setExitCode(ExitError)
+			}
+			return
+		}
+
+		if !machine {
+			fmt.Println(ui.DrawStatusBar(fmt.Sprintf("正在查询 %d 个目标...", len(targets)), ui.BgBrightBlue))
+		}
+
+		results := LookupTargets(targets, providers, workers)
+		setExitCode(lookupExitCode(results))
+
+		if machine {
+			if err := writeLookupResults(os.Stdout, output, results); err != nil {
+				fmt.Fprintln(os.Stderr, ui.DrawNotice("输出结果失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+				setExitCode(ExitError)
+			}
+			return
+		}
+
+		entries := make([]ui.LookupEntry, 0, len(results))
+		for _, result := range results {
+			entries = append(entries, ui.LookupEntry{
+				Target: result.Target,
+				IP:     result.IP,
+				Info:   result.Info,
+				Error:  result.Error,
+			})
+		}
+
+		if len(entries) > lookupTableThreshold {
+			fmt.Println(ui.RenderLookupTableWithLipgloss(entries))
+			return
+		}
+		for _, entry := range entries {
+			fmt.Println(ui.RenderLookupWithLipgloss(entry))
+		}
+	},
+}
+
+// LookupTargets 使用有限数量的并发worker查询多个IP或域名的详细信息
+// 域名会被解析为所有地址，每个地址对应一条结果；重复的目标和地址只查询一次，
+// 结果按目标的输入顺序返回
+func LookupTargets(targets []string, providers []myip.Provider, workers int) []LookupResult {
+	collected := make(map[int]LookupResult)
+	n := lookupTargets(targets, providers, workers, func(i int, result LookupResult) {
+		collected[i] = result
+	})
+
+	results := make([]LookupResult, n)
+	for i, result := range collected {
+		results[i] = result
+	}
+	return results
+}
+
+// lookupTargets 解析并查询所有目标，每得到一条结果就调用emit，返回结果总数
+// i为结果在输入顺序中的位置；emit按完成顺序调用，不会被并发调用
+func lookupTargets(targets []string, providers []myip.Provider, workers int, emit func(i int, result LookupResult)) int {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// 目标去重并解析为地址
+	targets = uniqueStrings(targets)
+	resolved := make([][]string, len(targets))
+	resolveErrs := make([]error, len(targets))
+	runWorkers(len(targets), workers, func(i int) {
+		resolved[i], resolveErrs[i] = resolveTarget(targets[i])
+	})
+
+	// 按输入顺序为每条结果分配位置，同一地址可能对应多个目标，只查询一次
+	var ips []string
+	slots := make(map[string][]int)
+	var slotTargets []string
+	for i, target := range targets {
+		if resolveErrs[i] != nil {
+			emit(len(slotTargets), LookupResult{Target: target, Error: resolveErrs[i].Error(), err: resolveErrs[i]})
+			slotTargets = append(slotTargets, target)
+			continue
+		}
+		for _, ip := range resolved[i] {
+			if _, ok := slots[ip]; !ok {
+				ips = append(ips, ip)
+			}
+			slots[ip] = append(slots[ip], len(slotTargets))
+			slotTargets = append(slotTargets, target)
+		}
+	}
+
+	var mu sync.Mutex
+	runWorkers(len(ips), workers, func(i int) {
+		ip := ips[i]
+		info, err := OnlineIpInfo(ip, providers...)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, slot := range slots[ip] {
+			emit(slot, newLookupResult(slotTargets[slot], ip, info, err))
+		}
+	})
+	return len(slotTargets)
+}
+
+// newLookupResult 根据查询结果生成单个地址的 LookupResult
+func newLookupResult(target string, ip string, info *model.IPInfo, err error) LookupResult {
+	result := LookupResult{Target: target, IP: ip, Info: info, err: err}
+	var aggregate *myip.AggregateError
+	if errors.As(err, &aggregate) {
+		for _, e := range aggregate.Errors {
+			result.Failures = append(result.Failures, e.Error())
+		}
+	}
+	if info == nil {
+		result.Error = "所有IP信息提供者都查询失败"
+		if errors.Is(err, myip.ErrNoNetwork) {
+			result.Error = "网络不可用，所有IP信息提供者都查询失败"
+		}
+	}
+	return result
+}
+
+// lookupExitCode 根据批量查询的结果确定退出码：全部失败时按失败原因，部分失败或使用了降级数据时为 ExitPartial
+func lookupExitCode(results []LookupResult) int {
+	failed, degraded := 0, false
+	var lastErr error
+	for _, result := range results {
+		if result.Info == nil {
+			failed++
+			lastErr = result.err
+		} else if result.err != nil {
+			degraded = true
+		}
+	}
+	switch {
+	case failed == len(results) && lastErr != nil:
+		return exitCodeFor(lastErr)
+	case failed > 0 || degraded:
+		return ExitPartial
+	}
+	return ExitOK
+}
+
+// runWorkers 使用固定数量的goroutine执行n个任务
+func runWorkers(n int, workers int, task func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				task(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// uniqueStrings 去除重复项并保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		unique = append(unique, v)
+	}
+	return unique
+}
+
+// readTargets 从文件读取查询目标，path为"-"时从标准输入读取
+// 每行可包含多个以空白或逗号分隔的目标，#开头的行为注释
+func readTargets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		targets = append(targets, fields...)
+	}
+	return targets, scanner.Err()
+}
+
+// resolveTarget 将查询目标解析为IP地址列表，目标本身是IP时直接返回
+func resolveTarget(target string) ([]string, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		return []string{ip.String()}, nil
+	}
+
+	ips, err := net.LookupIP(target)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析域名 %s: %v", target, err)
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	return addrs, nil
+}
+
+// writeLookupResults 按指定格式输出查询结果，ndjson格式在查询过程中逐行输出，不经过该函数
+func writeLookupResults(w io.Writer, format string, results []LookupResult) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(results)
+	case OutputCSV:
+		infoHeader, _ := csvFields(model.IPInfo{}, model.IPInfo{})
+		header := append([]string{"target", "query_ip", "error"}, infoHeader...)
+		records := make([][]string, 0, len(results))
+		for _, result := range results {
+			_, infoRecord := csvFields(result.Info, model.IPInfo{})
+			records = append(records, append([]string{result.Target, result.IP, result.Error}, infoRecord...))
+		}
+		return writeCSV(w, header, records)
+	}
+	return fmt.Errorf("不支持的输出格式: %s", format)
+}
+
+func init() {
+	rootCmd.AddCommand(lookupCmd)
+
+	lookupCmd.Flags().StringSlice("provider", nil, "指定IP信息提供者及其顺序，多个用逗号分隔")
+	lookupCmd.Flags().StringP("output", "o", OutputText, "输出格式: text, json, yaml, csv, ndjson")
+	lookupCmd.Flags().StringP("file", "f", "", "从文件读取要查询的目标，每行一个或多个（逗号/空白分隔），\"-\"表示标准输入")
+	lookupCmd.Flags().IntP("workers", "w", 8, "并发查询的worker数量")
+	lookupCmd.Flags().Float64("rate", defaultProviderRate, "每个提供者默认每秒最多发出的请求数，覆盖配置文件中的 rate_limits.default，0表示不限速；ip-api.com 等提供者保留各自的限速")
+}
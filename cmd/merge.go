@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/myip"
+)
+
+// mergeProviders 是否并发查询多个提供者并合并结果，而不是使用第一个成功的结果
+var mergeProviders bool
+
+// SetMergeProviders 设置是否合并多个提供者的结果
+func SetMergeProviders(enabled bool) {
+	mergeProviders = enabled
+}
+
+// mergeSkipFields 合并时不从提供者复制的字段，这些字段在合并后重新计算
+var mergeSkipFields = map[string]bool{
+	"is_pure":    true,
+	"pure_score": true,
+	"pure_type":  true,
+	"ip_type":    true,
+	"is_proxy":   true,
+	"is_dc":      true,
+	"api_source": true,
+	"sources":    true,
+	"conflicts":  true,
+}
+
+// mergeConflictFields 各提供者结果不一致时需要报告冲突的字段
+var mergeConflictFields = map[string]bool{
+	"country_code": true,
+	"region":       true,
+	"city":         true,
+	"asn":          true,
+	"timezone":     true,
+}
+
+// queryMerged 并发查询所有提供者并合并结果，配置的离线数据库也参与合并（优先级最低）
+// 所有提供者都失败时返回nil和每个提供者的失败原因
+func queryMerged(client *myip.Client, ip string, providers []myip.Provider) (*model.IPInfo, []*myip.SourceError) {
+	if offlineProvider != nil && !containsProvider(providers, offlineProvider) {
+		providers = append(providers[:len(providers):len(providers)], offlineProvider)
+	}
+
+	// 结果按提供者顺序存放，顺序即合并时的优先级
+	infos := make([]*model.IPInfo, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider myip.Provider) {
+			defer wg.Done()
+			infos[i], errs[i] = client.LookupProvider(context.Background(), provider, ip)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	succeeded := make([]*model.IPInfo, 0, len(infos))
+	var failed []*myip.SourceError
+	for i, info := range infos {
+		if info != nil {
+			succeeded = append(succeeded, info)
+		} else {
+			failed = append(failed, &myip.SourceError{Source: providers[i].Name(), Err: errs[i]})
+		}
+	}
+	if len(succeeded) == 0 {
+		return nil, failed
+	}
+	return mergeIPInfo(succeeded), failed
+}
+
+// mergeIPInfo 按优先级合并多个提供者的结果
+// 每个字段取第一个非空的值并记录来源，冲突字段记录每个提供者返回的值
+func mergeIPInfo(infos []*model.IPInfo) *model.IPInfo {
+	merged := &model.IPInfo{Sources: make(map[string]string)}
+	mv := reflect.ValueOf(merged).Elem()
+	mt := mv.Type()
+
+	for i := 0; i < mt.NumField(); i++ {
+		name := strings.Split(mt.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || mergeSkipFields[name] {
+			continue
+		}
+
+		values := make(map[string]string)
+		distinct := make(map[string]bool)
+		for _, info := range infos {
+			field := reflect.ValueOf(info).Elem().Field(i)
+			if field.IsZero() {
+				continue
+			}
+			if mv.Field(i).IsZero() {
+				mv.Field(i).Set(field)
+				merged.Sources[name] = info.APISource
+			}
+			if mergeConflictFields[name] {
+				value := csvValue(field)
+				values[info.APISource] = value
+				distinct[strings.ToLower(strings.TrimSpace(value))] = true
+			}
+		}
+
+		if len(distinct) > 1 {
+			if merged.Conflicts == nil {
+				merged.Conflicts = make(map[string]map[string]string)
+			}
+			merged.Conflicts[name] = values
+		}
+	}
+
+	sources := make([]string, 0, len(infos))
+	for _, info := range infos {
+		sources = append(sources, info.APISource)
+	}
+	return myip.CompleteIPInfo(merged, strings.Join(sources, ", "))
+}
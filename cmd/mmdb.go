@@ -0,0 +1,17 @@
+package cmd
+
+import "github.com/cikichen/MyIp/myip"
+
+// offlineProvider 配置的离线数据库提供者，在线API源都失败时自动使用
+var offlineProvider myip.LocalProvider
+
+// SetGeoDB 打开离线数据库并注册为 mmdb 提供者，同时作为在线API源失败时的备用
+func SetGeoDB(paths ...string) error {
+	provider, err := myip.NewMMDBProvider(paths...)
+	if err != nil {
+		return err
+	}
+	RegisterProvider(provider)
+	offlineProvider = provider
+	return nil
+}
@@ -5,12 +5,15 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"ip/network"
-	"ip/ui"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/cikichen/MyIp/myip"
+	"github.com/cikichen/MyIp/network"
+	"github.com/cikichen/MyIp/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -23,70 +26,78 @@ var nettestCmd = &cobra.Command{
   ip nettest
   ip nettest --url github.com,google.com
   ip nettest --timeout 15
-  ip nettest --detailed`,
+  ip nettest --detailed
+  ip nettest --count 5 --interval 500ms
+  ip nettest --watch --watch-interval 30s
+  ip nettest --output ndjson`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 获取参数
 		urlsFlag, _ := cmd.Flags().GetString("url")
 		timeout, _ := cmd.Flags().GetInt("timeout")
 		detailed, _ := cmd.Flags().GetBool("detailed")
-		
+		count, _ := cmd.Flags().GetInt("count")
+		watch, _ := cmd.Flags().GetBool("watch")
+		watchInterval, _ := cmd.Flags().GetDuration("watch-interval")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		output, _ := cmd.Flags().GetString("output")
+		if err := checkOutputFormat(output, OutputText, OutputJSON, OutputCSV, OutputNDJSON); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice(err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+		machine := output != OutputText
+
+		// 每个站点的HTTP测试重复count次，取中位数和百分位统计
+		network.SetSampleOptions(count, interval)
+
+		// 设置全局HTTP超时
+		if timeout > 0 {
+			network.SetGlobalTimeout(time.Duration(timeout) * time.Second)
+		}
+
+		// 处理自定义URL，未指定时测试常用站点
+		var sites []network.Site
+		if urlsFlag != "" {
+			sites = parseSiteURLs(urlsFlag)
+		}
+
+		// 持续监控模式，全屏显示并按间隔重新探测
+		if watch {
+			if machine {
+				fmt.Println(ui.DrawNotice("--watch 只支持文本输出", ui.IconWarning, ui.BgBrightRed))
+				setExitCode(ExitError)
+				return
+			}
+			client := myip.NewClient(nil)
+			err := ui.RunWatch(ui.WatchOptions{
+				Interval: watchInterval,
+				Probe: func(ctx context.Context) []network.SiteTestResult {
+					results, _ := client.TestSites(ctx, sites)
+					return results
+				},
+				Detail: getSiteDetailCard,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.DrawNotice("监控界面运行失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+				setExitCode(ExitError)
+			}
+			return
+		}
+
 		// 显示网络测试的状态栏
-		fmt.Println(ui.DrawStatusBar("正在测试站点连通性...", ui.BgBrightBlue))
+		if !machine {
+			fmt.Println(ui.DrawStatusBar("正在测试站点连通性...", ui.BgBrightBlue))
+		}
 		
-		// 处理自定义URL
 		var siteResults []network.SiteTestResult
 		
-		if urlsFlag != "" {
-			// 用户提供了自定义URL
-			customUrls := strings.Split(urlsFlag, ",")
-			sites := make([]struct{
-				Name string
-				URL  string
-			}, 0, len(customUrls))
-			
-			for _, url := range customUrls {
-				url = strings.TrimSpace(url)
-				if url == "" {
-					continue
-				}
-				
-				// 确保URL格式正确
-				if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-					url = "https://" + url
-				}
-				
-				// 从URL中提取名称
-				name := url
-				name = strings.TrimPrefix(name, "http://")
-				name = strings.TrimPrefix(name, "https://")
-				name = strings.TrimPrefix(name, "www.")
-				name = strings.Split(name, "/")[0]
-				name = strings.Split(name, ".")[0]
-				name = strings.Title(name)
-				
-				sites = append(sites, struct{
-					Name string
-					URL  string
-				}{
-					Name: name,
-					URL:  url,
-				})
-			}
-			
-			// 设置全局HTTP超时
-			if timeout > 0 {
-				network.SetGlobalTimeout(time.Duration(timeout) * time.Second)
-			}
-			
+		if len(sites) > 0 {
 			// 测试自定义站点
 			results := make([]network.SiteTestResult, 0, len(sites))
 			resultChan := make(chan network.SiteTestResult, len(sites))
 			
 			for _, site := range sites {
-				go func(s struct{
-					Name string
-					URL  string
-				}) {
+				go func(s network.Site) {
 					resultChan <- network.TestSite(s)
 				}(site)
 			}
@@ -98,16 +109,19 @@ var nettestCmd = &cobra.Command{
 			
 			siteResults = results
 		} else {
-			// 使用默认的常用站点
-			// 设置全局HTTP超时
-			if timeout > 0 {
-				network.SetGlobalTimeout(time.Duration(timeout) * time.Second)
-			}
-			
 			// 测试常用站点连通性
 			siteResults = network.TestCommonSites()
 		}
 
+		// 机器可读格式直接输出完整结果
+		if machine {
+			if err := writeNettestReport(os.Stdout, output, siteResults); err != nil {
+				fmt.Fprintln(os.Stderr, ui.DrawNotice("输出结果失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+				setExitCode(ExitError)
+			}
+			return
+		}
+
 		// 使用新的 lipgloss 布局显示网络测试结果
 		fmt.Println(ui.RenderNetworkTestWithLipgloss(siteResults))
 		
@@ -119,6 +133,34 @@ var nettestCmd = &cobra.Command{
 	},
 }
 
+// parseSiteURLs 解析逗号分隔的URL列表，缺少协议时补全为https，站点名称取自域名
+func parseSiteURLs(urls string) []network.Site {
+	var sites []network.Site
+	for _, url := range strings.Split(urls, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		
+		// 确保URL格式正确
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			url = "https://" + url
+		}
+		
+		// 从URL中提取名称
+		name := url
+		name = strings.TrimPrefix(name, "http://")
+		name = strings.TrimPrefix(name, "https://")
+		name = strings.TrimPrefix(name, "www.")
+		name = strings.Split(name, "/")[0]
+		name = strings.Split(name, ".")[0]
+		name = strings.Title(name)
+		
+		sites = append(sites, network.Site{Name: name, URL: url})
+	}
+	return sites
+}
+
 // getDetailedTestInfo 返回详细的测试信息
 func getDetailedTestInfo(results []network.SiteTestResult) string {
 	var sb strings.Builder
@@ -127,58 +169,126 @@ func getDetailedTestInfo(results []network.SiteTestResult) string {
 	sb.WriteString("\n\n")
 	
 	for _, result := range results {
-		// 构建每个站点的详细信息卡片
-		var siteInfo strings.Builder
-		
-		// 基本信息
-		siteInfo.WriteString(fmt.Sprintf("%s站点URL:%s %s\n", ui.Bold, ui.Reset, result.URL))
-		
-		// 状态信息
-		statusText := ui.BrightGreen + "成功" + ui.Reset
-		if !result.Accessible {
-			statusText = ui.BrightRed + "失败 - " + result.Error + ui.Reset
-		}
-		siteInfo.WriteString(fmt.Sprintf("%s访问状态:%s %s\n", ui.Bold, ui.Reset, statusText))
-		
-		if result.Accessible {
-			siteInfo.WriteString(fmt.Sprintf("%s状态码:%s %d\n", ui.Bold, ui.Reset, result.StatusCode))
-		}
-		
-		// 时间指标
-		if result.Accessible {
-			siteInfo.WriteString(fmt.Sprintf("%s总响应时间:%s %.2f秒\n", ui.Bold, ui.Reset, result.ResponseTime.Seconds()))
-		}
-		siteInfo.WriteString(fmt.Sprintf("%sDNS解析时间:%s %.2f秒\n", ui.Bold, ui.Reset, result.DNSTime.Seconds()))
-		siteInfo.WriteString(fmt.Sprintf("%s连接建立时间:%s %.2f秒\n", ui.Bold, ui.Reset, result.ConnectTime.Seconds()))
-		
-		// Ping和Generate204
-		pingTimeText := "超时"
-		if result.PingTime > 0 {
-			pingTimeText = fmt.Sprintf("%.1f毫秒", float64(result.PingTime)/float64(time.Millisecond))
+		sb.WriteString(getSiteDetailCard(result))
+		sb.WriteString("\n\n")
+	}
+	
+	return sb.String()
+}
+
+// getSiteDetailCard 返回单个站点的详细测试信息卡片
+func getSiteDetailCard(result network.SiteTestResult) string {
+	// 构建站点的详细信息
+	var siteInfo strings.Builder
+	
+	// 基本信息
+	siteInfo.WriteString(fmt.Sprintf("%s站点URL:%s %s\n", ui.Bold, ui.Reset, result.URL))
+	
+	// 状态信息
+	statusText := ui.BrightGreen + "成功" + ui.Reset
+	if !result.Accessible {
+		statusText = ui.BrightRed + "失败 - " + result.Error + ui.Reset
+	}
+	siteInfo.WriteString(fmt.Sprintf("%s访问状态:%s %s\n", ui.Bold, ui.Reset, statusText))
+	
+	if result.Accessible {
+		siteInfo.WriteString(fmt.Sprintf("%s状态码:%s %d\n", ui.Bold, ui.Reset, result.StatusCode))
+	}
+	
+	// 时间指标
+	if result.Accessible {
+		label := "总响应时间"
+		if result.Samples > 1 {
+			label = "总响应时间(中位数)"
 		}
-		siteInfo.WriteString(fmt.Sprintf("%sPing延迟:%s %s\n", ui.Bold, ui.Reset, pingTimeText))
-		
-		siteInfo.WriteString(fmt.Sprintf("%sPing丢包率:%s %.1f%%\n", ui.Bold, ui.Reset, result.PingLoss*100))
-		
-		gen204Text := "超时"
-		if result.Generate204 > 0 {
-			gen204Text = fmt.Sprintf("%.1f毫秒", float64(result.Generate204)/float64(time.Millisecond))
+		siteInfo.WriteString(fmt.Sprintf("%s%s:%s %.2f秒\n", ui.Bold, label, ui.Reset, result.ResponseTime.Seconds()))
+	}
+	if result.Samples > 1 {
+		siteInfo.WriteString(fmt.Sprintf("%s采样次数:%s %d (成功%d)\n", ui.Bold, ui.Reset, result.Samples, result.Succeeded))
+		siteInfo.WriteString(getLatencyStatsText("响应时间", result.ResponseStats))
+		siteInfo.WriteString(getLatencyStatsText("DNS解析", result.DNSStats))
+		siteInfo.WriteString(getLatencyStatsText("连接建立", result.ConnectStats))
+	}
+	siteInfo.WriteString(getHopTimingText(result.Hops))
+	
+	// Ping和Generate204
+	pingTimeText := "超时"
+	if result.PingTime > 0 {
+		pingTimeText = fmt.Sprintf("%.1f毫秒", float64(result.PingTime)/float64(time.Millisecond))
+	}
+	siteInfo.WriteString(fmt.Sprintf("%sPing延迟:%s %s\n", ui.Bold, ui.Reset, pingTimeText))
+	if result.PingMethod != "" {
+		siteInfo.WriteString(fmt.Sprintf("%sPing方式:%s %s\n", ui.Bold, ui.Reset, pingMethodText(result.PingMethod)))
+	}
+	
+	siteInfo.WriteString(fmt.Sprintf("%sPing丢包率:%s %.1f%%\n", ui.Bold, ui.Reset, result.PingLoss*100))
+	
+	gen204Text := "超时"
+	if result.Generate204 > 0 {
+		gen204Text = fmt.Sprintf("%.1f毫秒", float64(result.Generate204)/float64(time.Millisecond))
+	}
+	siteInfo.WriteString(fmt.Sprintf("%sGenerate_204延迟:%s %s", ui.Bold, ui.Reset, gen204Text))
+	
+	// 创建站点卡片
+	cardColor := ui.BrightGreen
+	if !result.Accessible {
+		cardColor = ui.BrightRed
+	}
+	return ui.DrawCard(result.Name, ui.IconGlobe, siteInfo.String(), 60, cardColor)
+}
+
+// getHopTimingText 返回每一跳请求各阶段耗时的文本，格式类似 curl -w 的计时输出
+func getHopTimingText(hops []network.HTTPTiming) string {
+	var sb strings.Builder
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%8.1f毫秒", float64(d)/float64(time.Millisecond))
+	}
+	for i, hop := range hops {
+		status := "-"
+		if hop.StatusCode > 0 {
+			status = fmt.Sprint(hop.StatusCode)
 		}
-		siteInfo.WriteString(fmt.Sprintf("%sGenerate_204延迟:%s %s", ui.Bold, ui.Reset, gen204Text))
-		
-		// 创建站点卡片
-		cardColor := ui.BrightGreen
-		if !result.Accessible {
-			cardColor = ui.BrightRed
+		sb.WriteString(fmt.Sprintf("%s第%d跳:%s [%s] %s\n", ui.Bold, i+1, ui.Reset, status, hop.URL))
+		if hop.Reused {
+			sb.WriteString("  (复用已有连接)\n")
 		}
-		siteCard := ui.DrawCard(result.Name, ui.IconGlobe, siteInfo.String(), 60, cardColor)
-		sb.WriteString(siteCard)
-		sb.WriteString("\n\n")
+		sb.WriteString(fmt.Sprintf("  DNS解析:    %s\n", ms(hop.DNS)))
+		sb.WriteString(fmt.Sprintf("  TCP连接:    %s\n", ms(hop.Connect)))
+		sb.WriteString(fmt.Sprintf("  TLS握手:    %s\n", ms(hop.TLSHandshake)))
+		sb.WriteString(fmt.Sprintf("  服务器处理: %s\n", ms(hop.ServerProcessing)))
+		sb.WriteString(fmt.Sprintf("  内容传输:   %s\n", ms(hop.ContentTransfer)))
+		sb.WriteString(fmt.Sprintf("  首字节:     %s\n", ms(hop.TTFB)))
+		sb.WriteString(fmt.Sprintf("  合计:       %s\n", ms(hop.Total)))
 	}
-	
 	return sb.String()
 }
 
+// getLatencyStatsText 返回多次采样的延迟分布文本
+func getLatencyStatsText(label string, stats network.LatencyStats) string {
+	if stats.Samples == 0 {
+		return fmt.Sprintf("%s%s:%s 无成功采样\n", ui.Bold, label, ui.Reset)
+	}
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return fmt.Sprintf("%s%s:%s 最小 %.1f / 中位 %.1f / P90 %.1f / P99 %.1f / 最大 %.1f / 标准差 %.1f 毫秒\n",
+		ui.Bold, label, ui.Reset,
+		ms(stats.Min), ms(stats.Median), ms(stats.P90), ms(stats.P99), ms(stats.Max), ms(stats.StdDev))
+}
+
+// pingMethodText 返回Ping方式的说明
+func pingMethodText(method string) string {
+	switch method {
+	case network.PingMethodICMPUDP:
+		return "ICMP (数据报套接字)"
+	case network.PingMethodICMPRaw:
+		return "ICMP (原始套接字)"
+	case network.PingMethodTCP:
+		return "TCP握手 (ICMP不可用)"
+	}
+	return method
+}
+
 func init() {
 	rootCmd.AddCommand(nettestCmd)
 
@@ -186,4 +296,13 @@ func init() {
 	nettestCmd.Flags().StringP("url", "u", "", "要测试的站点URL，多个URL用逗号分隔")
 	nettestCmd.Flags().IntP("timeout", "t", 0, "设置HTTP请求超时时间(秒)")
 	nettestCmd.Flags().BoolP("detailed", "d", false, "显示详细的测试信息")
+	nettestCmd.Flags().Int("count", 1, "每个站点HTTP测试的采样次数，大于1时统计中位数和百分位")
+	nettestCmd.Flags().Duration("interval", time.Second, "多次采样之间的间隔")
+	nettestCmd.Flags().BoolP("watch", "w", false, "持续监控模式，全屏实时显示各站点的状态、延迟趋势和丢包率")
+	nettestCmd.Flags().Duration("watch-interval", 10*time.Second, "持续监控模式下两轮探测之间的间隔")
+	nettestCmd.Flags().Int("ping-count", network.DefaultPingOptions.Count, "每个站点发送的Ping包数")
+	nettestCmd.Flags().Duration("ping-interval", network.DefaultPingOptions.Interval, "Ping的发包间隔")
+	nettestCmd.Flags().Int("ping-size", network.DefaultPingOptions.Size, "Ping的ICMP负载字节数")
+	nettestCmd.Flags().Int("ping-port", 0, "ICMP被屏蔽时TCP探测的端口，默认根据URL协议使用443或80")
+	nettestCmd.Flags().StringP("output", "o", OutputText, "输出格式: text, json, csv, ndjson (csv和ndjson每行一个站点，最后一行为汇总)")
 } 
\ No newline at end of file
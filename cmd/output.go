@@ -0,0 +1,386 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/network"
+	"github.com/cikichen/MyIp/ui"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的输出格式
+const (
+	OutputText   = "text"
+	OutputJSON   = "json"
+	OutputYAML   = "yaml"
+	OutputCSV    = "csv"
+	OutputNDJSON = "ndjson"
+)
+
+// IPReport 机器可读输出的IP查询结果
+// LocalIP 和 PublicIP 为首选地址（优先IPv4）
+type IPReport struct {
+	LocalIP    string                  `json:"local_ip" yaml:"local_ip"`
+	LocalIPv4  string                  `json:"local_ipv4" yaml:"local_ipv4"`
+	LocalIPv6  string                  `json:"local_ipv6" yaml:"local_ipv6"`
+	PublicIP   string                  `json:"public_ip" yaml:"public_ip"`
+	PublicIPv4 string                  `json:"public_ipv4" yaml:"public_ipv4"`
+	PublicIPv6 string                  `json:"public_ipv6" yaml:"public_ipv6"`
+	Info       *model.IPInfo           `json:"info" yaml:"info"`
+	Consensus  []*PublicIPConsensus    `json:"consensus,omitempty" yaml:"consensus,omitempty"`
+	Interfaces []network.InterfaceInfo `json:"interfaces,omitempty" yaml:"interfaces,omitempty"`
+}
+
+// newIPReport 根据查询到的地址和IP信息构建机器可读结果
+func newIPReport(addrs ui.Addresses, info *model.IPInfo, consensus []*PublicIPConsensus) IPReport {
+	return IPReport{
+		LocalIP:    addrs.LocalIP(),
+		LocalIPv4:  addrs.LocalIPv4,
+		LocalIPv6:  addrs.LocalIPv6,
+		PublicIP:   addrs.PublicIP(),
+		PublicIPv4: addrs.PublicIPv4,
+		PublicIPv6: addrs.PublicIPv6,
+		Info:       info,
+		Consensus:  consensus,
+	}
+}
+
+// SiteRecord 机器可读输出的单个站点测试结果，时间单位为毫秒
+type SiteRecord struct {
+	Timestamp     time.Time     `json:"timestamp"`
+	Name          string        `json:"name"`
+	URL           string        `json:"url"`
+	Accessible    bool          `json:"accessible"`
+	StatusCode    int           `json:"status_code"`
+	Error         string        `json:"error"`
+	ResponseTime  float64       `json:"response_time_ms"`
+	DNSTime       float64       `json:"dns_time_ms"`
+	ConnectTime   float64       `json:"connect_time_ms"`
+	TLSTime       float64       `json:"tls_time_ms"`
+	ServerTime    float64       `json:"server_time_ms"`
+	TransferTime  float64       `json:"transfer_time_ms"`
+	Hops          []HopRecord   `json:"hops"`
+	Samples       int           `json:"samples"`
+	Succeeded     int           `json:"succeeded"`
+	ResponseStats LatencyRecord `json:"response_stats"`
+	DNSStats      LatencyRecord `json:"dns_stats"`
+	ConnectStats  LatencyRecord `json:"connect_stats"`
+	PingTime      float64       `json:"ping_time_ms"`
+	PingLoss      float64       `json:"ping_loss"`
+	PingMethod    string        `json:"ping_method"`
+	Generate204   float64       `json:"generate_204_ms"`
+}
+
+// HopRecord 机器可读输出的单跳HTTP请求耗时，时间单位为毫秒
+type HopRecord struct {
+	URL              string  `json:"url"`
+	StatusCode       int     `json:"status_code"`
+	Reused           bool    `json:"reused"`
+	DNS              float64 `json:"dns_ms"`
+	Connect          float64 `json:"connect_ms"`
+	TLSHandshake     float64 `json:"tls_ms"`
+	ServerProcessing float64 `json:"server_ms"`
+	ContentTransfer  float64 `json:"transfer_ms"`
+	TTFB             float64 `json:"ttfb_ms"`
+	Total            float64 `json:"total_ms"`
+}
+
+// LatencyRecord 机器可读输出的多次采样延迟分布，时间单位为毫秒
+type LatencyRecord struct {
+	Samples int     `json:"samples"`
+	Min     float64 `json:"min_ms"`
+	Median  float64 `json:"median_ms"`
+	P90     float64 `json:"p90_ms"`
+	P99     float64 `json:"p99_ms"`
+	Max     float64 `json:"max_ms"`
+	StdDev  float64 `json:"stddev_ms"`
+}
+
+// SummaryRecord 机器可读输出的测试汇总，时间单位为毫秒
+type SummaryRecord struct {
+	Timestamp          time.Time `json:"timestamp"`
+	TotalSites         int       `json:"total_sites"`
+	AccessibleSites    int       `json:"accessible_sites"`
+	AccessRate         float64   `json:"access_rate"`
+	AvgResponseTime    float64   `json:"avg_response_time_ms"`
+	AvgPingTime        float64   `json:"avg_ping_time_ms"`
+	AvgGenerate204     float64   `json:"avg_generate_204_ms"`
+	MedianResponseTime float64   `json:"median_response_time_ms"`
+	MedianPingTime     float64   `json:"median_ping_time_ms"`
+	MedianGenerate204  float64   `json:"median_generate_204_ms"`
+	Rating             string    `json:"rating"`
+}
+
+// NettestReport 机器可读输出的网络测试结果
+type NettestReport struct {
+	Sites   []SiteRecord  `json:"sites"`
+	Summary SummaryRecord `json:"summary"`
+}
+
+// checkOutputFormat 校验输出格式是否在允许的范围内
+func checkOutputFormat(format string, allowed ...string) error {
+	for _, f := range allowed {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的输出格式: %s (可用: %s)", format, strings.Join(allowed, ", "))
+}
+
+// writeIPReport 按指定格式输出IP查询结果
+func writeIPReport(w io.Writer, format string, report IPReport) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	case OutputCSV:
+		header := []string{"local_ip", "local_ipv4", "local_ipv6", "public_ip", "public_ipv4", "public_ipv6"}
+		record := []string{report.LocalIP, report.LocalIPv4, report.LocalIPv6, report.PublicIP, report.PublicIPv4, report.PublicIPv6}
+		infoHeader, infoRecord := csvFields(report.Info, model.IPInfo{})
+		return writeCSV(w, append(header, infoHeader...), [][]string{append(record, infoRecord...)})
+	}
+	return fmt.Errorf("不支持的输出格式: %s", format)
+}
+
+// writeCSV 输出带表头的CSV
+func writeCSV(w io.Writer, header []string, records [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// csvFields 根据结构体的json标签生成CSV表头和对应的值
+// v为nil指针时返回空值，zero用于在v为nil时推导表头
+func csvFields(v interface{}, zero interface{}) ([]string, []string) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			header, values := csvFields(zero, zero)
+			for i := range values {
+				values[i] = ""
+			}
+			return header, values
+		}
+		rv = rv.Elem()
+	}
+
+	rt := rv.Type()
+	header := make([]string, 0, rt.NumField())
+	values := make([]string, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		header = append(header, name)
+		values = append(values, csvValue(rv.Field(i)))
+	}
+	return header, values
+}
+
+// csvValue 将字段值格式化为CSV单元格内容
+func csvValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Map, reflect.Slice:
+		// 嵌套结构以JSON写入单元格
+		if v.IsNil() {
+			return ""
+		}
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	case reflect.Struct:
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// milliseconds 将时间转换为毫秒
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// newSiteRecord 将站点测试结果转换为机器可读记录
+func newSiteRecord(result network.SiteTestResult, now time.Time) SiteRecord {
+	return SiteRecord{
+		Timestamp:     now,
+		Name:          result.Name,
+		URL:           result.URL,
+		Accessible:    result.Accessible,
+		StatusCode:    result.StatusCode,
+		Error:         result.Error,
+		ResponseTime:  milliseconds(result.ResponseTime),
+		DNSTime:       milliseconds(result.DNSTime),
+		ConnectTime:   milliseconds(result.ConnectTime),
+		TLSTime:       milliseconds(result.TLSTime),
+		ServerTime:    milliseconds(result.ServerTime),
+		TransferTime:  milliseconds(result.TransferTime),
+		Hops:          newHopRecords(result.Hops),
+		Samples:       result.Samples,
+		Succeeded:     result.Succeeded,
+		ResponseStats: newLatencyRecord(result.ResponseStats),
+		DNSStats:      newLatencyRecord(result.DNSStats),
+		ConnectStats:  newLatencyRecord(result.ConnectStats),
+		PingTime:      milliseconds(result.PingTime),
+		PingLoss:      result.PingLoss,
+		PingMethod:    result.PingMethod,
+		Generate204:   milliseconds(result.Generate204),
+	}
+}
+
+// newHopRecords 将每一跳的耗时转换为机器可读记录
+func newHopRecords(hops []network.HTTPTiming) []HopRecord {
+	records := make([]HopRecord, 0, len(hops))
+	for _, hop := range hops {
+		records = append(records, HopRecord{
+			URL:              hop.URL,
+			StatusCode:       hop.StatusCode,
+			Reused:           hop.Reused,
+			DNS:              milliseconds(hop.DNS),
+			Connect:          milliseconds(hop.Connect),
+			TLSHandshake:     milliseconds(hop.TLSHandshake),
+			ServerProcessing: milliseconds(hop.ServerProcessing),
+			ContentTransfer:  milliseconds(hop.ContentTransfer),
+			TTFB:             milliseconds(hop.TTFB),
+			Total:            milliseconds(hop.Total),
+		})
+	}
+	return records
+}
+
+// newLatencyRecord 将延迟分布转换为机器可读记录
+func newLatencyRecord(stats network.LatencyStats) LatencyRecord {
+	return LatencyRecord{
+		Samples: stats.Samples,
+		Min:     milliseconds(stats.Min),
+		Median:  milliseconds(stats.Median),
+		P90:     milliseconds(stats.P90),
+		P99:     milliseconds(stats.P99),
+		Max:     milliseconds(stats.Max),
+		StdDev:  milliseconds(stats.StdDev),
+	}
+}
+
+// newSummaryRecord 将测试汇总转换为机器可读记录
+func newSummaryRecord(summary network.Summary, now time.Time) SummaryRecord {
+	return SummaryRecord{
+		Timestamp:          now,
+		TotalSites:         summary.TotalSites,
+		AccessibleSites:    summary.AccessibleSites,
+		AccessRate:         summary.AccessRate,
+		AvgResponseTime:    milliseconds(summary.AvgResponseTime),
+		AvgPingTime:        milliseconds(summary.AvgPingTime),
+		AvgGenerate204:     milliseconds(summary.AvgGenerate204),
+		MedianResponseTime: milliseconds(summary.MedianResponseTime),
+		MedianPingTime:     milliseconds(summary.MedianPingTime),
+		MedianGenerate204:  milliseconds(summary.MedianGenerate204),
+		Rating:             summary.Rating(),
+	}
+}
+
+// writeNettestReport 按指定格式输出网络测试结果
+// csv和ndjson格式每行一个站点，最后一行为汇总，通过type字段区分
+func writeNettestReport(w io.Writer, format string, results []network.SiteTestResult) error {
+	// 按名称排序结果的副本，保证输出稳定且不修改调用方的切片
+	results = append([]network.SiteTestResult(nil), results...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	now := time.Now()
+	report := NettestReport{
+		Sites:   make([]SiteRecord, 0, len(results)),
+		Summary: newSummaryRecord(network.Summarize(results), now),
+	}
+	for _, result := range results {
+		report.Sites = append(report.Sites, newSiteRecord(result, now))
+	}
+
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case OutputNDJSON:
+		// 每行带上type字段，便于区分站点记录和汇总记录
+		encoder := json.NewEncoder(w)
+		for _, site := range report.Sites {
+			line := struct {
+				Type string `json:"type"`
+				SiteRecord
+			}{"site", site}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+		return encoder.Encode(struct {
+			Type string `json:"type"`
+			SummaryRecord
+		}{"summary", report.Summary})
+	case OutputCSV:
+		// 第一列为type，站点行和最后的汇总行共用表头，汇总行只填写汇总字段
+		siteHeader, _ := csvFields(SiteRecord{}, SiteRecord{})
+		summaryHeader, summaryValues := csvFields(report.Summary, SummaryRecord{})
+		header := append([]string{"type"}, siteHeader...)
+		columns := make(map[string]int, len(header))
+		for i, name := range header {
+			columns[name] = i
+		}
+		for _, name := range summaryHeader {
+			if _, ok := columns[name]; !ok {
+				columns[name] = len(header)
+				header = append(header, name)
+			}
+		}
+
+		records := make([][]string, 0, len(report.Sites)+1)
+		for _, site := range report.Sites {
+			_, values := csvFields(site, SiteRecord{})
+			record := make([]string, len(header))
+			record[0] = "site"
+			copy(record[1:], values)
+			records = append(records, record)
+		}
+		summary := make([]string, len(header))
+		summary[0] = "summary"
+		for i, name := range summaryHeader {
+			summary[columns[name]] = summaryValues[i]
+		}
+		return writeCSV(w, header, append(records, summary))
+	}
+	return fmt.Errorf("不支持的输出格式: %s", format)
+}
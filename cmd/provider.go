@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cikichen/MyIp/myip"
+)
+
+var (
+	providersMu sync.RWMutex
+	// providers 已注册的提供者，内置提供者在包变量初始化阶段注册，
+	// 保证各命令的 init 中可以读取到完整列表
+	providers = myip.DefaultProviders()
+	// defaultProviderNames 未指定 --provider 时使用的提供者顺序，为空表示所有已注册的提供者
+	defaultProviderNames []string
+)
+
+// RegisterProvider 注册一个IP信息提供者，同名提供者会被替换
+// 注册顺序即为默认的故障转移顺序
+func RegisterProvider(p myip.Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	for i, existing := range providers {
+		if strings.EqualFold(existing.Name(), p.Name()) {
+			providers[i] = p
+			return
+		}
+	}
+	providers = append(providers, p)
+}
+
+// Providers 返回所有已注册的提供者（按注册顺序）
+func Providers() []myip.Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	list := make([]myip.Provider, len(providers))
+	copy(list, providers)
+	return list
+}
+
+// onlineProviders 过滤掉离线数据库等本地提供者，以及必须配置API密钥但尚未配置的提供者
+func onlineProviders(list []myip.Provider) []myip.Provider {
+	online := make([]myip.Provider, 0, len(list))
+	for _, p := range myip.UsableProviders(list) {
+		if _, ok := p.(myip.LocalProvider); !ok {
+			online = append(online, p)
+		}
+	}
+	return online
+}
+
+// LookupProvider 按名称查找已注册的提供者（不区分大小写）
+func LookupProvider(name string) (myip.Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	for _, p := range providers {
+		if strings.EqualFold(p.Name(), name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// SelectProviders 根据名称列表选择提供者，名称为空时返回配置的默认提供者
+// 都未指定时返回nil，由 OnlineIpInfo 根据健康状态自动选择提供者顺序
+func SelectProviders(names []string) ([]myip.Provider, error) {
+	selected := make([]myip.Provider, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		p, ok := LookupProvider(name)
+		if !ok {
+			return nil, fmt.Errorf("未知的IP信息提供者: %s (可用: %s)", name, strings.Join(ProviderNames(), ", "))
+		}
+		selected = append(selected, p)
+	}
+
+	if len(selected) == 0 {
+		if len(defaultProviderNames) > 0 {
+			return SelectProviders(defaultProviderNames)
+		}
+		return nil, nil
+	}
+	return selected, nil
+}
+
+// SetDefaultProviders 设置未指定 --provider 时使用的提供者及其顺序
+func SetDefaultProviders(names []string) error {
+	valid := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := LookupProvider(name); !ok {
+			return fmt.Errorf("未知的IP信息提供者: %s (可用: %s)", name, strings.Join(ProviderNames(), ", "))
+		}
+		valid = append(valid, name)
+	}
+	defaultProviderNames = valid
+	return nil
+}
+
+// ProviderNames 返回所有已注册提供者的名称
+func ProviderNames() []string {
+	list := Providers()
+	names := make([]string, 0, len(list))
+	for _, p := range list {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// rateLimiter 按固定间隔放行请求的限速器
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Wait 阻塞直到允许发出下一个请求
+func (l *rateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+		l.next = now
+	}
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+// defaultProviderRate 每个提供者默认每秒最多发出的请求数
+// 取值保守，避免批量查询时触发免费API的频率限制
+const defaultProviderRate = 1.0
+
+var (
+	rateLimitMu sync.Mutex
+	rateDefault = defaultProviderRate
+	// rateOverrides 单独设置限速的提供者，键为小写的提供者名称
+	// 内置值为免费额度更严格的提供者的默认限速
+	rateOverrides = map[string]float64{
+		"ip-api.com": 0.75, // 免费额度为每分钟45次
+	}
+	rateLimiters = make(map[string]*rateLimiter)
+)
+
+// SetProviderRateLimit 设置提供者默认每秒最多发出的请求数，0表示不限速
+// 单独设置了限速的提供者（包括内置的默认值）不受影响，需通过 SetProviderRate 修改
+func SetProviderRateLimit(perSecond float64) {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+
+	rateDefault = perSecond
+	for name := range rateLimiters {
+		if _, ok := rateOverrides[name]; !ok {
+			delete(rateLimiters, name)
+		}
+	}
+}
+
+// SetProviderRate 设置单个提供者每秒最多发出的请求数，0表示不限速
+func SetProviderRate(name string, perSecond float64) {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+
+	name = strings.ToLower(name)
+	rateOverrides[name] = perSecond
+	delete(rateLimiters, name)
+}
+
+// waitProviderRate 按提供者的限速等待，未限速时立即返回
+func waitProviderRate(name string) {
+	name = strings.ToLower(name)
+
+	rateLimitMu.Lock()
+	rate, ok := rateOverrides[name]
+	if !ok {
+		rate = rateDefault
+	}
+	if rate <= 0 {
+		rateLimitMu.Unlock()
+		return
+	}
+	limiter, ok := rateLimiters[name]
+	if !ok {
+		limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+		rateLimiters[name] = limiter
+	}
+	rateLimitMu.Unlock()
+
+	limiter.Wait()
+}
+
+// SetProviderAPIKey 为指定提供者设置API密钥，替换已注册的同名提供者
+func SetProviderAPIKey(name string, key string) error {
+	p, ok := LookupProvider(name)
+	if !ok {
+		return fmt.Errorf("未知的IP信息提供者: %s", name)
+	}
+	auth, ok := p.(myip.AuthProvider)
+	if !ok {
+		return fmt.Errorf("IP信息提供者 %s 不支持API密钥", name)
+	}
+	RegisterProvider(auth.WithAPIKey(key))
+	return nil
+}
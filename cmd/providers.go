@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cikichen/MyIp/ui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// providersCmd 管理IP信息提供者
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "查看和管理IP信息提供者",
+	Long: `查看和管理IP信息提供者。
+每个提供者的成功率和平均延迟会在多次运行之间保存（位于 $XDG_CACHE_HOME/myip/health.json），
+未指定 --provider 时按成功率和延迟加权随机选择提供者顺序；
+连续失败多次或返回HTTP 429的提供者会被暂时熔断并跳过。`,
+}
+
+// providersStatusCmd 显示提供者的健康状态
+var providersStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "显示各提供者的成功率、延迟和熔断状态",
+	Long: `显示各提供者的成功率、延迟和熔断状态。
+例如:
+  ip providers status
+  ip providers status --output json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		if err := checkOutputFormat(output, OutputText, OutputJSON, OutputYAML); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice(err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+
+		if err := writeProviderStatus(os.Stdout, output, ProviderHealthStatus()); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice("输出结果失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+		}
+	},
+}
+
+// providersResetCmd 清除提供者的健康状态
+var providersResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "清除所有提供者的健康记录并解除熔断",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ResetProviderHealth(); err != nil {
+			fmt.Fprintln(os.Stderr, ui.DrawNotice("清除健康记录失败: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
+			setExitCode(ExitError)
+			return
+		}
+		fmt.Println(ui.DrawNotice("已清除所有提供者的健康记录", ui.IconCheck, ui.BgBrightGreen))
+	},
+}
+
+// writeProviderStatus 按指定格式输出提供者的健康状态
+func writeProviderStatus(w io.Writer, format string, status []ProviderHealth) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(status)
+	}
+
+	rows := make([]ui.ProviderStatus, 0, len(status))
+	for _, h := range status {
+		rows = append(rows, ui.ProviderStatus{
+			Name:         h.Name,
+			State:        h.State,
+			Successes:    h.Successes,
+			Failures:     h.Failures,
+			SuccessRate:  h.SuccessRate(),
+			AvgLatencyMs: h.AvgLatencyMs,
+			LastError:    h.LastError,
+			OpenUntil:    h.OpenUntil,
+		})
+	}
+	_, err := fmt.Fprintln(w, ui.RenderProviderStatusWithLipgloss(rows))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+	providersCmd.AddCommand(providersStatusCmd)
+	providersCmd.AddCommand(providersResetCmd)
+
+	providersStatusCmd.Flags().StringP("output", "o", OutputText, "输出格式: text, json, yaml")
+}
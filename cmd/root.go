@@ -5,11 +5,8 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"fmt"
-	"ip/ui"
 	"os"
-	"sort"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -24,121 +21,27 @@ var rootCmd = &cobra.Command{
 - 地理位置信息（国家、城市、经纬度等）
 - 网络提供商(ISP)和网络类型
 - IP类型和质量评分
-- 其他相关信息（货币、时区等）`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// 检查是否需要测试API源
-		testAPI, _ := cmd.Flags().GetBool("test-api")
-		if testAPI {
-			fmt.Println(ui.DrawNotice("正在测试所有API源的可用性...", ui.IconInfo, ui.BgBrightBlue))
-			results := TestAPISource()
+- 其他相关信息（货币、时区等）
 
-			// 显示测试结果
-			fmt.Println("")
-			// 构建漂亮的API测试结果表格
-			var tableContent strings.Builder
-			validCount := 0
-			
-			tableContent.WriteString(fmt.Sprintf("%s%-20s%s | %s%-10s%s\n", ui.Bold, "API 源", ui.Reset, ui.Bold, "状态", ui.Reset))
-			tableContent.WriteString(strings.Repeat("─", 33) + "\n")
-			
-			// 按名称排序显示结果
-			names := make([]string, 0, len(results))
-			for name := range results {
-				names = append(names, name)
-			}
-			sort.Strings(names)
-			
-			for _, name := range names {
-				valid := results[name]
-				status := ui.BrightRed + "不可用 " + ui.IconCross + ui.Reset
-				if valid {
-					status = ui.BrightGreen + "可用 " + ui.IconCheck + ui.Reset
-					validCount++
-				}
-				tableContent.WriteString(fmt.Sprintf("%-20s | %s\n", name, status))
-			}
-			
-			tableContent.WriteString(strings.Repeat("─", 33) + "\n")
-			// 添加总结行
-			tableContent.WriteString(fmt.Sprintf(
-				"%s%-20s%s | %s%d/%d%s",
-				ui.Bold, "总计", ui.Reset,
-				ui.BrightYellow + ui.Bold, validCount, len(results), ui.Reset,
-			))
-			
-			// 创建结果卡片
-			resultCard := ui.DrawCard("API源测试结果", ui.IconServer, tableContent.String(), 50, ui.BrightBlue)
-			fmt.Println(resultCard)
-			
-			// 显示测试完成消息
-			fmt.Println(ui.DrawNotice("API源测试完成！", ui.IconCheck, ui.BgBrightGreen))
-			return
+退出码:
+  0  成功
+  1  参数、配置或输出错误
+  2  网络不可用
+  3  所有公网IP源或IP信息提供者都失败
+  4  只获取到部分数据（结果来自离线数据库或过期缓存，或批量查询中部分目标失败）`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 读取配置文件和环境变量，设置提供者、缓存、离线数据库、超时和评级阈值
+		if err := initConfig(cmd); err != nil {
+			cmd.SilenceUsage = true
+			return err
 		}
-
-		// 获取本地IP
-		localIp, err := externalIP()
-		if err != nil {
-			fmt.Println(ui.DrawNotice("无法获取本地IP地址: "+err.Error(), ui.IconWarning, ui.BgBrightRed))
-			return
-		}
-
-		// 显示获取公网IP的状态栏
-		fmt.Println(ui.DrawStatusBar("正在获取公网IP地址...", ui.BgBrightBlue))
-		
-		// 获取公网IP
-		myIP := GetMyPublicIP()
-
-		// 显示获取IP信息的状态栏
-		fmt.Println(ui.DrawStatusBar("正在获取IP详细信息...", ui.BgBrightBlue))
-		
-		// 获取IP信息（使用负载均衡机制）
-		result := OnlineIpInfo(myIP)
-
-		// 使用卡片式UI显示结果
-		var uiInfo *ui.IPInfo
-		if result != nil {
-			// 转换为ui.IPInfo类型
-			uiInfo = &ui.IPInfo{
-				IP:             result.IP,
-				Network:        result.Network,
-				Version:        result.Version,
-				City:           result.City,
-				Region:         result.Region,
-				RegionCode:     result.RegionCode,
-				Country:        result.Country,
-				CountryName:    result.CountryName,
-				CountryCode:    result.CountryCode,
-				CountryCodeISO: result.CountryCodeISO,
-				CountryCapital: result.CountryCapital,
-				CountryTLD:     result.CountryTLD,
-				ContinentCode:  result.ContinentCode,
-				InEU:           result.InEU,
-				Postal:         result.Postal,
-				Latitude:       result.Latitude,
-				Longitude:      result.Longitude,
-				Timezone:       result.Timezone,
-				UTCOffset:      result.UTCOffset,
-				CallingCode:    result.CallingCode,
-				Currency:       result.Currency,
-				CurrencyName:   result.CurrencyName,
-				Languages:      result.Languages,
-				CountryArea:    result.CountryArea,
-				Population:     result.Population,
-				ASN:            result.ASN,
-				Org:            result.Org,
-				IsPure:         result.IsPure,
-				PureScore:      result.PureScore,
-				PureType:       result.PureType,
-				IPType:         result.IPType,
-				IsProxy:        result.IsProxy,
-				IsDC:           result.IsDC,
-				APISource:      result.APISource,
-			}
-		}
-		
-		// 显示IP信息
-		fmt.Println(ui.DrawIPInfo(localIp.String(), myIP, uiInfo))
+		return nil
 	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		// 保存本次运行中各提供者的成功率、延迟和熔断状态
+		saveProviderHealth()
+	},
+	Run: runIP,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -146,7 +49,10 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(ExitError)
+	}
+	if exitCode != ExitOK {
+		os.Exit(exitCode)
 	}
 }
 
@@ -155,12 +61,24 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ip.yaml)")
+	// 配置文件，也可以通过环境变量 MYIP_CONFIG 指定
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "配置文件路径，支持YAML/TOML (默认依次查找 $XDG_CONFIG_HOME/myip/config.yaml 和 $HOME/.ip.yaml)")
+
+	// 离线数据库，所有子命令都可以使用
+	rootCmd.PersistentFlags().StringSlice("geo-db", nil, "离线GeoLite2/DB-IP数据库(.mmdb)路径，可同时指定City和ASN库，多个用逗号分隔")
+
+	// IP信息缓存，缓存位于 $XDG_CACHE_HOME/myip
+	rootCmd.PersistentFlags().Bool("no-cache", false, "不读取也不写入IP信息缓存")
+	rootCmd.PersistentFlags().Bool("refresh", false, "忽略已有缓存，强制重新查询并更新缓存")
+	rootCmd.PersistentFlags().Duration("cache-ttl", time.Hour, "IP信息缓存的有效期，所有API源都失败时仍会使用过期缓存")
+
+	// 诊断日志，输出到标准错误
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "向标准错误输出每个公网IP源和IP信息提供者的尝试结果")
+	rootCmd.PersistentFlags().Bool("debug", false, "在 --verbose 的基础上输出请求URL、配置文件、缓存命中和熔断跳过等调试信息")
 
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "帮助信息示例")
+	// 合并多个提供者的结果
+	rootCmd.PersistentFlags().Bool("merge", false, "并发查询所有IP信息提供者，合并各字段并报告结果不一致的字段")
 
-	// 添加测试API源的标志
-	rootCmd.Flags().Bool("test-api", false, "仅测试所有IP信息API源的可用性，不获取IP信息")
+	// 显示IP信息的标志，与 ipCmd 共用
+	addIPFlags(rootCmd)
 }
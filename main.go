@@ -4,7 +4,7 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 */
 package main
 
-import "ip/cmd"
+import "github.com/cikichen/MyIp/cmd"
 
 func main() {
 	cmd.Execute()
@@ -0,0 +1,63 @@
+// Package model 定义IP信息的数据模型，由IP信息提供者、IP类型判断和界面渲染共用
+package model
+
+// IPInfo IP地址的详细信息
+// 字段的 json 标签与 ipapi.co 的响应一致，其他提供者的响应会转换为该结构
+type IPInfo struct {
+	IP             string  `json:"ip" yaml:"ip"`
+	Network        string  `json:"network" yaml:"network"`
+	Version        string  `json:"version" yaml:"version"`
+	City           string  `json:"city" yaml:"city"`
+	Region         string  `json:"region" yaml:"region"`
+	RegionCode     string  `json:"region_code" yaml:"region_code"`
+	Country        string  `json:"country" yaml:"country"`
+	CountryName    string  `json:"country_name" yaml:"country_name"`
+	CountryCode    string  `json:"country_code" yaml:"country_code"`
+	CountryCodeISO string  `json:"country_code_iso3" yaml:"country_code_iso3"`
+	CountryCapital string  `json:"country_capital" yaml:"country_capital"`
+	CountryTLD     string  `json:"country_tld" yaml:"country_tld"`
+	ContinentCode  string  `json:"continent_code" yaml:"continent_code"`
+	InEU           bool    `json:"in_eu" yaml:"in_eu"`
+	Postal         string  `json:"postal" yaml:"postal"`
+	Latitude       float64 `json:"latitude" yaml:"latitude"`
+	Longitude      float64 `json:"longitude" yaml:"longitude"`
+	Timezone       string  `json:"timezone" yaml:"timezone"`
+	UTCOffset      string  `json:"utc_offset" yaml:"utc_offset"`
+	CallingCode    string  `json:"country_calling_code" yaml:"country_calling_code"`
+	Currency       string  `json:"currency" yaml:"currency"`
+	CurrencyName   string  `json:"currency_name" yaml:"currency_name"`
+	Languages      string  `json:"languages" yaml:"languages"`
+	CountryArea    float64 `json:"country_area" yaml:"country_area"`
+	Population     int64   `json:"country_population" yaml:"country_population"`
+	ASN            string  `json:"asn" yaml:"asn"`
+	Org            string  `json:"org" yaml:"org"`
+	// 需要API密钥才返回的扩展字段（如 ipinfo.io 的 asn/company/privacy/abuse）
+	ASNDomain      string `json:"asn_domain,omitempty" yaml:"asn_domain,omitempty"`
+	ASNRoute       string `json:"asn_route,omitempty" yaml:"asn_route,omitempty"`
+	ASNType        string `json:"asn_type,omitempty" yaml:"asn_type,omitempty"` // isp/hosting/business/education
+	CompanyName    string `json:"company_name,omitempty" yaml:"company_name,omitempty"`
+	CompanyDomain  string `json:"company_domain,omitempty" yaml:"company_domain,omitempty"`
+	CompanyType    string `json:"company_type,omitempty" yaml:"company_type,omitempty"`
+	PrivacyVPN     bool   `json:"privacy_vpn" yaml:"privacy_vpn"`
+	PrivacyProxy   bool   `json:"privacy_proxy" yaml:"privacy_proxy"`
+	PrivacyTor     bool   `json:"privacy_tor" yaml:"privacy_tor"`
+	PrivacyRelay   bool   `json:"privacy_relay" yaml:"privacy_relay"`
+	PrivacyHosting bool   `json:"privacy_hosting" yaml:"privacy_hosting"`
+	PrivacyService string `json:"privacy_service,omitempty" yaml:"privacy_service,omitempty"` // VPN服务商名称
+	AbuseName      string `json:"abuse_name,omitempty" yaml:"abuse_name,omitempty"`
+	AbuseEmail     string `json:"abuse_email,omitempty" yaml:"abuse_email,omitempty"`
+	AbusePhone     string `json:"abuse_phone,omitempty" yaml:"abuse_phone,omitempty"`
+	AbuseAddress   string `json:"abuse_address,omitempty" yaml:"abuse_address,omitempty"`
+	AbuseNetwork   string `json:"abuse_network,omitempty" yaml:"abuse_network,omitempty"`
+	// 额外字段，不是API直接返回的
+	IsPure    bool   `json:"is_pure" yaml:"is_pure"`
+	PureScore int    `json:"pure_score" yaml:"pure_score"`
+	PureType  string `json:"pure_type" yaml:"pure_type"`
+	IPType    string `json:"ip_type" yaml:"ip_type"`       // 家宽/独立IP/共享IP
+	IsProxy   bool   `json:"is_proxy" yaml:"is_proxy"`     // 是否是代理IP
+	IsDC      bool   `json:"is_dc" yaml:"is_dc"`           // 是否是数据中心IP
+	APISource string `json:"api_source" yaml:"api_source"` // 记录数据来源的API
+	// 合并模式(--merge)下每个字段的来源提供者，以及各提供者结果不一致的字段: 字段 -> 提供者 -> 值
+	Sources   map[string]string            `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Conflicts map[string]map[string]string `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+}
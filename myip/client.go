@@ -0,0 +1,219 @@
+package myip
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/network"
+)
+
+// defaultHTTPClient 未指定 HTTPClient 时使用的客户端
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Client 获取公网IP、查询IP信息和测试站点连通性的客户端，零值即可使用
+// 所有方法都通过返回值报告错误，不会向标准输出或标准错误打印内容
+type Client struct {
+	// HTTPClient 查询公网IP和IP信息使用的HTTP客户端，为nil时使用超时10秒的默认客户端
+	HTTPClient *http.Client
+	// Providers 查询IP信息的提供者，按顺序故障转移，为空时使用 DefaultProviders 中不缺少API密钥的提供者
+	Providers []Provider
+	// PublicIPSources 获取公网IP的API源，按顺序故障转移，为空时使用 DefaultPublicIPSources
+	PublicIPSources []string
+	// Hooks 查询过程中的回调，可用于接入缓存、健康统计、限速和日志
+	Hooks Hooks
+}
+
+// Hooks 客户端在查询过程中调用的回调，未设置的回调会被忽略
+// 并发调用 Client 的方法时回调也会被并发调用
+type Hooks struct {
+	// CachedInfo 查询在线提供者前调用，返回true时直接使用返回的结果，不再发送请求
+	CachedInfo func(provider Provider, ip string) (*model.IPInfo, bool)
+	// BeforeRequest 向在线提供者发送请求前调用，可用于限速和记录请求
+	BeforeRequest func(provider Provider, req *http.Request)
+	// AfterLookup 提供者查询结束后调用（缓存命中时不调用），成功时info为未补全派生字段的结果
+	AfterLookup func(provider Provider, ip string, info *model.IPInfo, latency time.Duration, err error)
+	// PublicIPResult 每个公网IP源请求结束后调用
+	PublicIPResult func(source string, network string, ip string, latency time.Duration, err error)
+}
+
+// NewClient 创建使用指定HTTP客户端的 Client，httpClient 为nil时使用默认客户端
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{HTTPClient: httpClient}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return defaultHTTPClient
+}
+
+func (c *Client) providers() []Provider {
+	if len(c.Providers) > 0 {
+		return c.Providers
+	}
+	return UsableProviders(DefaultProviders())
+}
+
+func (c *Client) publicIPSources() []string {
+	if len(c.PublicIPSources) > 0 {
+		return c.PublicIPSources
+	}
+	return DefaultPublicIPSources
+}
+
+// PublicIP 按顺序尝试各公网IP源，返回第一个成功获取的公网IP
+// 协议族由 HTTPClient 的拨号方式决定，需要指定IPv4或IPv6时使用 PublicIPFamily
+// 所有源都失败时返回 *AggregateError
+func (c *Client) PublicIP(ctx context.Context) (string, error) {
+	return c.PublicIPFamily(ctx, "tcp")
+}
+
+// PublicIPFamily 通过指定网络获取公网IP
+// network 为 tcp4 或 tcp6 时强制对应协议族拨号：HTTPClient 的 Transport 为 *http.Transport（或未设置）时
+// 克隆该 Transport 并只替换 DialContext，代理、TLS等设置保持不变；其他 RoundTripper 无法控制拨号，原样使用。
+// 为 tcp 时直接使用 HTTPClient。所有源都失败时返回 *AggregateError
+func (c *Client) PublicIPFamily(ctx context.Context, network string) (string, error) {
+	client := c.httpClient()
+	op := "获取公网IP"
+	if network != "tcp" {
+		client = withNetwork(client, network)
+		op += "(" + network + ")"
+	}
+
+	failed := &AggregateError{Op: op}
+	for _, url := range c.publicIPSources() {
+		start := time.Now()
+		ip, err := FetchPublicIP(ctx, client, url, network)
+		if c.Hooks.PublicIPResult != nil {
+			c.Hooks.PublicIPResult(url, network, ip, time.Since(start), err)
+		}
+		if err == nil {
+			return ip, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		failed.Errors = append(failed.Errors, &SourceError{Source: url, Err: err})
+	}
+	return "", failed
+}
+
+// Lookup 按顺序尝试各提供者查询IP信息，并补全版本、网络、IP类型和纯净度等派生字段
+// ip为空时查询请求方自身，不支持自查询的提供者会被跳过；所有提供者都失败时返回 *AggregateError
+func (c *Client) Lookup(ctx context.Context, ip string) (*model.IPInfo, error) {
+	failed := &AggregateError{Op: "查询 " + ip + " 的IP信息"}
+	if ip == "" {
+		failed.Op = "查询IP信息"
+	}
+	for _, provider := range c.providers() {
+		if ip == "" && !provider.Capabilities().SelfLookup {
+			continue
+		}
+		info, err := c.LookupProvider(ctx, provider, ip)
+		if err == nil {
+			return info, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		failed.Errors = append(failed.Errors, &SourceError{Source: provider.Name(), Err: err})
+	}
+	return nil, failed
+}
+
+// LookupProvider 使用单个提供者查询IP信息，并补全派生字段
+// 在线提供者先通过 Hooks.CachedInfo 查找缓存，本地提供者直接查询
+func (c *Client) LookupProvider(ctx context.Context, provider Provider, ip string) (*model.IPInfo, error) {
+	_, local := provider.(LocalProvider)
+	if !local && c.Hooks.CachedInfo != nil {
+		if info, ok := c.Hooks.CachedInfo(provider, ip); ok {
+			return CompleteIPInfo(info, provider.Name()), nil
+		}
+	}
+
+	start := time.Now()
+	info, err := c.lookupProvider(ctx, provider, ip)
+	if c.Hooks.AfterLookup != nil {
+		c.Hooks.AfterLookup(provider, ip, info, time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return CompleteIPInfo(info, provider.Name()), nil
+}
+
+// lookupProvider 使用单个提供者查询IP信息
+func (c *Client) lookupProvider(ctx context.Context, provider Provider, ip string) (*model.IPInfo, error) {
+	if local, ok := provider.(LocalProvider); ok {
+		return local.Lookup(ip)
+	}
+	req, err := provider.NewRequest(ip)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.Hooks.BeforeRequest != nil {
+		c.Hooks.BeforeRequest(provider, req)
+	}
+	return DoRequest(c.httpClient(), provider, req)
+}
+
+// LocalInterfaces 返回所有本地网络接口及其地址
+func (c *Client) LocalInterfaces() ([]network.InterfaceInfo, error) {
+	return network.ListInterfaces()
+}
+
+// TestSites 并发测试站点的可访问性、响应时间和延迟，结果与 sites 顺序一致
+// sites 为空时测试 network.CommonSites；站点测试需要测量DNS和连接时间，使用独立的HTTP客户端
+func (c *Client) TestSites(ctx context.Context, sites []network.Site) ([]network.SiteTestResult, error) {
+	if len(sites) == 0 {
+		sites = network.CommonSites
+	}
+
+	results := make([]network.SiteTestResult, len(sites))
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func(i int, site network.Site) {
+			defer wg.Done()
+			results[i] = network.TestSiteContext(ctx, site)
+		}(i, site)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// DoRequest 发送提供者构建的请求并解析响应，非200状态码返回 *StatusError，响应无法解析时返回 *ParseError
+func DoRequest(client *http.Client, provider Provider, req *http.Request) (*model.IPInfo, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+	// 密钥无效(401/403)或超出配额(429)时不解析响应
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewStatusError(provider.Name(), resp)
+	}
+
+	// 解析响应
+	info, err := provider.Parse(out)
+	if err != nil {
+		return nil, &ParseError{Provider: provider.Name(), Err: err}
+	}
+	return info, nil
+}
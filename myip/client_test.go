@@ -0,0 +1,31 @@
+package myip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPublicIPFamilyKeepsTransport 指定协议族时保留注入客户端的TLS等设置
+func TestPublicIPFamilyKeepsTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("127.0.0.1\n"))
+	}))
+	defer srv.Close()
+
+	// srv.Client() 的 Transport 信任测试服务器的自签名证书
+	client := &Client{HTTPClient: srv.Client(), PublicIPSources: []string{srv.URL}}
+	ip, err := client.PublicIPFamily(context.Background(), "tcp4")
+	if err != nil {
+		t.Fatalf("PublicIPFamily(tcp4) 失败: %v", err)
+	}
+	if ip != "127.0.0.1" {
+		t.Errorf("ip = %q, 期望 127.0.0.1", ip)
+	}
+
+	// 只支持IPv4的服务器无法通过IPv6访问
+	if _, err := client.PublicIPFamily(context.Background(), "tcp6"); err == nil {
+		t.Error("PublicIPFamily(tcp6) 期望失败")
+	}
+}
@@ -0,0 +1,99 @@
+package myip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ErrNoNetwork 所有请求都因网络不可达而失败，例如DNS解析失败、连接被拒绝或超时
+var ErrNoNetwork = errors.New("网络不可用")
+
+// ErrAPIKeyRequired 提供者必须设置API密钥才能使用
+var ErrAPIKeyRequired = errors.New("需要API密钥")
+
+// SourceError 单个公网IP源或IP信息提供者的失败
+type SourceError struct {
+	Source string // 公网IP源的URL或提供者名称
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error { return e.Err }
+
+// ParseError 提供者返回了200状态码，但响应内容无法解析为IP信息
+type ParseError struct {
+	Provider string // 提供者名称
+	Err      error
+}
+
+func (e *ParseError) Error() string { return e.Err.Error() }
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// AggregateError 所有公网IP源或IP信息提供者都失败，按尝试顺序列出每个源的失败原因
+// 每个源都因网络不可达失败时 errors.Is(err, ErrNoNetwork) 为 true
+type AggregateError struct {
+	Op     string // 失败的操作，例如 "获取公网IP"
+	Errors []*SourceError
+}
+
+func (e *AggregateError) Error() string {
+	if len(e.Errors) == 0 {
+		return e.Op + "失败: 没有可用的源"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s失败，%d个源都不可用:", e.Op, len(e.Errors))
+	for _, err := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 返回每个源的错误，全部为网络错误时同时返回 ErrNoNetwork
+// 返回 []error 需要 Go 1.20 的 errors.Is/As 才能逐个匹配，go.mod 因此要求 go 1.20
+func (e *AggregateError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors)+1)
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	if e.NoNetwork() {
+		errs = append(errs, ErrNoNetwork)
+	}
+	return errs
+}
+
+// NoNetwork 判断是否每个发出了请求的源都因网络不可达而失败，缺少API密钥而未发出请求的源不计入
+func (e *AggregateError) NoNetwork() bool {
+	network := false
+	for _, err := range e.Errors {
+		switch {
+		case IsNetworkError(err.Err):
+			network = true
+		case !errors.Is(err.Err, ErrAPIKeyRequired):
+			return false
+		}
+	}
+	return network
+}
+
+// IsNetworkError 判断错误是否由网络不可达引起（DNS解析失败、拨号失败或超时），
+// HTTP状态码错误和响应解析错误不属于网络错误
+func IsNetworkError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
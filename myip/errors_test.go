@@ -0,0 +1,47 @@
+package myip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestAggregateErrorIs(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "ipinfo.io"}
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: network is unreachable")}
+	keyErr := fmt.Errorf("ipgeolocation.io %w", ErrAPIKeyRequired)
+	statusErr := &StatusError{Provider: "ipapi.co", StatusCode: 429}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		noNetwork bool
+	}{
+		{"全部为网络错误", []error{dnsErr, dialErr}, true},
+		{"缺少API密钥的源不计入", []error{dnsErr, keyErr}, true},
+		{"只有缺少API密钥", []error{keyErr}, false},
+		{"包含HTTP状态码错误", []error{dnsErr, statusErr}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := &AggregateError{Op: "查询IP信息"}
+			for i, err := range tt.errs {
+				agg.Errors = append(agg.Errors, &SourceError{Source: fmt.Sprint("source", i), Err: err})
+			}
+			// 包装后仍能通过 errors.Is 匹配
+			var err error = fmt.Errorf("wrapped: %w", agg)
+			if got := errors.Is(err, ErrNoNetwork); got != tt.noNetwork {
+				t.Errorf("errors.Is(err, ErrNoNetwork) = %v, 期望 %v", got, tt.noNetwork)
+			}
+
+			var target *AggregateError
+			if !errors.As(err, &target) || target != agg {
+				t.Error("errors.As 未能取出 *AggregateError")
+			}
+			if errors.Is(tt.errs[0], ErrAPIKeyRequired) && !errors.Is(err, ErrAPIKeyRequired) {
+				t.Error("errors.Is 未能匹配源错误")
+			}
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package myip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cikichen/MyIp/model"
+)
+
+// parseIpapiResponse 解析ipapi.co的响应
+func parseIpapiResponse(data []byte) (*model.IPInfo, error) {
+	var ipInfo model.IPInfo
+	err := json.Unmarshal(data, &ipInfo)
+	if err != nil {
+		return nil, fmt.Errorf("解析ipapi.co响应失败: %v", err)
+	}
+
+	// 密钥无效或超出配额时返回 {"error": true, "reason": "..."}
+	var apiError struct {
+		Error   bool   `json:"error"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &apiError) == nil && apiError.Error {
+		return nil, fmt.Errorf("ipapi.co返回错误: %s %s", apiError.Reason, apiError.Message)
+	}
+
+	// 验证必要字段
+	if ipInfo.IP == "" {
+		return nil, fmt.Errorf("ipapi.co响应缺少IP字段")
+	}
+
+	return &ipInfo, nil
+}
+
+// parseIpinfoResponse 解析ipinfo.io的响应
+func parseIpinfoResponse(data []byte) (*model.IPInfo, error) {
+	// ipinfo.io返回的JSON结构与我们的IPInfo结构不完全匹配，需要特殊处理
+	var response struct {
+		IP       string `json:"ip"`
+		Hostname string `json:"hostname"`
+		City     string `json:"city"`
+		Region   string `json:"region"`
+		Country  string `json:"country"`
+		Loc      string `json:"loc"`
+		Org      string `json:"org"`
+		Postal   string `json:"postal"`
+		Timezone string `json:"timezone"`
+		// 以下字段需要API令牌，是否返回取决于订阅套餐
+		ASN *struct {
+			ASN    string `json:"asn"`
+			Name   string `json:"name"`
+			Domain string `json:"domain"`
+			Route  string `json:"route"`
+			Type   string `json:"type"`
+		} `json:"asn"`
+		Company *struct {
+			Name   string `json:"name"`
+			Domain string `json:"domain"`
+			Type   string `json:"type"`
+		} `json:"company"`
+		Privacy *struct {
+			VPN     bool   `json:"vpn"`
+			Proxy   bool   `json:"proxy"`
+			Tor     bool   `json:"tor"`
+			Relay   bool   `json:"relay"`
+			Hosting bool   `json:"hosting"`
+			Service string `json:"service"`
+		} `json:"privacy"`
+		Abuse *struct {
+			Name    string `json:"name"`
+			Email   string `json:"email"`
+			Phone   string `json:"phone"`
+			Address string `json:"address"`
+			Network string `json:"network"`
+		} `json:"abuse"`
+	}
+
+	err := json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, fmt.Errorf("解析ipinfo.io响应失败: %v", err)
+	}
+
+	// 验证必要字段
+	if response.IP == "" {
+		return nil, fmt.Errorf("ipinfo.io响应缺少IP字段")
+	}
+
+	// 解析经纬度
+	var lat, lon float64
+	if response.Loc != "" {
+		coords := strings.Split(response.Loc, ",")
+		if len(coords) == 2 {
+			lat, _ = strconv.ParseFloat(coords[0], 64)
+			lon, _ = strconv.ParseFloat(coords[1], 64)
+		}
+	}
+
+	// 从组织信息中提取ASN和组织名称
+	asn := ""
+	orgName := response.Org
+	if response.Org != "" {
+		parts := strings.SplitN(response.Org, " ", 2)
+		if len(parts) > 0 && strings.HasPrefix(parts[0], "AS") {
+			asn = parts[0]
+			if len(parts) > 1 {
+				orgName = strings.TrimSpace(parts[1])
+			} else {
+				orgName = ""
+			}
+		}
+	}
+
+	// 获取国家名称
+	countryName := getCountryName(response.Country)
+
+	// 设置货币和通信区号
+	currency, currencyName := getCurrencyInfo(response.Country)
+	callingCode := getCallingCode(response.Country)
+
+	// 创建IPInfo实例
+	ipInfo := &model.IPInfo{
+		IP:            response.IP,
+		City:          response.City,
+		Region:        response.Region,
+		Country:       response.Country,
+		CountryName:   countryName,
+		CountryCode:   response.Country,
+		Postal:        response.Postal,
+		Timezone:      response.Timezone,
+		Latitude:      lat,
+		Longitude:     lon,
+		Org:           orgName,
+		ASN:           asn,
+		Currency:      currency,
+		CurrencyName:  currencyName,
+		CallingCode:   callingCode,
+		Network:       getNetworkFromIP(response.IP),
+		Version:       getIPVersion(response.IP),
+		ContinentCode: getContinentCode(response.Country),
+	}
+
+	// 带令牌时的ASN详情，部分套餐不再返回顶层的 org 字段
+	if response.ASN != nil {
+		if ipInfo.ASN == "" {
+			ipInfo.ASN = response.ASN.ASN
+		}
+		if ipInfo.Org == "" {
+			ipInfo.Org = response.ASN.Name
+		}
+		ipInfo.ASNDomain = response.ASN.Domain
+		ipInfo.ASNRoute = response.ASN.Route
+		ipInfo.ASNType = response.ASN.Type
+	}
+	if response.Company != nil {
+		ipInfo.CompanyName = response.Company.Name
+		ipInfo.CompanyDomain = response.Company.Domain
+		ipInfo.CompanyType = response.Company.Type
+	}
+	if response.Privacy != nil {
+		ipInfo.PrivacyVPN = response.Privacy.VPN
+		ipInfo.PrivacyProxy = response.Privacy.Proxy
+		ipInfo.PrivacyTor = response.Privacy.Tor
+		ipInfo.PrivacyRelay = response.Privacy.Relay
+		ipInfo.PrivacyHosting = response.Privacy.Hosting
+		ipInfo.PrivacyService = response.Privacy.Service
+	}
+	if response.Abuse != nil {
+		ipInfo.AbuseName = response.Abuse.Name
+		ipInfo.AbuseEmail = response.Abuse.Email
+		ipInfo.AbusePhone = response.Abuse.Phone
+		ipInfo.AbuseAddress = response.Abuse.Address
+		ipInfo.AbuseNetwork = response.Abuse.Network
+	}
+
+	return ipInfo, nil
+}
+
+// getCountryName 根据国家代码获取国家名称
+func getCountryName(countryCode string) string {
+	countries := map[string]string{
+		"CN": "China",
+		"US": "United States",
+		"JP": "Japan",
+		"GB": "United Kingdom",
+		"DE": "Germany",
+		"FR": "France",
+		"IT": "Italy",
+		"CA": "Canada",
+		"AU": "Australia",
+		"BR": "Brazil",
+		"IN": "India",
+		"RU": "Russia",
+		// 可以根据需要添加更多
+	}
+
+	if name, ok := countries[countryCode]; ok {
+		return name
+	}
+	return countryCode
+}
+
+// getCurrencyInfo 根据国家代码获取货币信息
+func getCurrencyInfo(countryCode string) (string, string) {
+	currencies := map[string]struct {
+		Code string
+		Name string
+	}{
+		"CN": {"CNY", "Yuan Renminbi"},
+		"US": {"USD", "US Dollar"},
+		"JP": {"JPY", "Japanese Yen"},
+		"GB": {"GBP", "Pound Sterling"},
+		"DE": {"EUR", "Euro"},
+		"FR": {"EUR", "Euro"},
+		"IT": {"EUR", "Euro"},
+		"CA": {"CAD", "Canadian Dollar"},
+		"AU": {"AUD", "Australian Dollar"},
+		"BR": {"BRL", "Brazilian Real"},
+		"IN": {"INR", "Indian Rupee"},
+		"RU": {"RUB", "Russian Ruble"},
+		// 可以根据需要添加更多
+	}
+
+	if currency, ok := currencies[countryCode]; ok {
+		return currency.Code, currency.Name
+	}
+	return "", ""
+}
+
+// getCallingCode 根据国家代码获取通信区号
+func getCallingCode(countryCode string) string {
+	callingCodes := map[string]string{
+		"CN": "+86",
+		"US": "+1",
+		"JP": "+81",
+		"GB": "+44",
+		"DE": "+49",
+		"FR": "+33",
+		"IT": "+39",
+		"CA": "+1",
+		"AU": "+61",
+		"BR": "+55",
+		"IN": "+91",
+		"RU": "+7",
+		// 可以根据需要添加更多
+	}
+
+	if code, ok := callingCodes[countryCode]; ok {
+		return code
+	}
+	return ""
+}
+
+// getNetworkFromIP 从IP获取网络信息，IPv4按/24、IPv6按/64计算
+func getNetworkFromIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	mask := net.CIDRMask(64, 128)
+	if v4 := parsed.To4(); v4 != nil {
+		parsed = v4
+		mask = net.CIDRMask(24, 32)
+	}
+	network := &net.IPNet{IP: parsed.Mask(mask), Mask: mask}
+	return network.String()
+}
+
+// getIPVersion 返回IP地址的版本（IPv4/IPv6）
+func getIPVersion(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// getContinentCode 根据国家代码获取大洲代码
+func getContinentCode(countryCode string) string {
+	continents := map[string]string{
+		"CN": "AS", // Asia
+		"JP": "AS",
+		"IN": "AS",
+		"US": "NA", // North America
+		"CA": "NA",
+		"GB": "EU", // Europe
+		"DE": "EU",
+		"FR": "EU",
+		"IT": "EU",
+		"BR": "SA", // South America
+		"AU": "OC", // Oceania
+		"RU": "EU", // Russia spans Europe and Asia
+		// 可以根据需要添加更多
+	}
+
+	if code, ok := continents[countryCode]; ok {
+		return code
+	}
+	return ""
+}
+
+// DetermineIPType 判断IP类型（家宽/独立IP/共享IP）
+func DetermineIPType(ipInfo *model.IPInfo) {
+	// 默认为未知类型
+	ipInfo.IPType = "未知"
+	ipInfo.IsDC = false
+	ipInfo.IsProxy = false
+
+	// 优先使用提供者返回的隐私检测结果
+	if ipInfo.PrivacyVPN || ipInfo.PrivacyProxy || ipInfo.PrivacyTor || ipInfo.PrivacyRelay {
+		ipInfo.IPType = "代理IP"
+		ipInfo.IsProxy = true
+		return
+	}
+	if ipInfo.PrivacyHosting || ipInfo.ASNType == "hosting" {
+		ipInfo.IPType = "数据中心IP"
+		ipInfo.IsDC = true
+		return
+	}
+	if ipInfo.ASNType == "mobile" {
+		ipInfo.IPType = "移动网络IP"
+		return
+	}
+
+	// 如果组织信息为空，设置为默认家庭宽带IP
+	if ipInfo.Org == "" && ipInfo.ASN == "" {
+		ipInfo.IPType = "家庭宽带IP"
+		return
+	}
+
+	// 标准化组织信息，移除换行符并转换为小写
+	org := strings.ToLower(strings.ReplaceAll(ipInfo.Org, "\n", " "))
+	asn := strings.ToLower(ipInfo.ASN)
+
+	// 检查是否包含数据中心相关关键词
+	dataCenterKeywords := []string{
+		"cloud", "hosting", "server", "data center",
+		"aws", "amazon", "azure", "google cloud", "gcp",
+		"alibaba", "tencent", "digital ocean", "linode",
+		"vultr", "scaleway", "oracle", "ibm",
+	}
+
+	// 检查是否包含代理相关关键词
+	proxyKeywords := []string{
+		"proxy", "vpn", "tor", "anonymizer", "anonymous",
+		"tunnel", "exit node", "relay",
+	}
+
+	// 检查是否包含移动网络相关关键词
+	mobileKeywords := []string{
+		"mobile", "wireless", "cellular", "lte", "5g", "4g", "3g",
+		"phone", "telecom", "cmcc", "china mobile", "unicom",
+	}
+
+	// 检查是否为中国家庭宽带ISP关键词
+	homeKeywords := []string{
+		"chinanet", "china telecom", "china unicom", "china mobile",
+		"residential", "home", "broadband", "fttx", "adsl", "vdsl",
+		"家宽", "家庭宽带", "联通", "电信", "移动宽带",
+	}
+
+	// 检查数据中心
+	for _, keyword := range dataCenterKeywords {
+		if strings.Contains(org, keyword) || strings.Contains(asn, keyword) {
+			ipInfo.IPType = "数据中心IP"
+			ipInfo.IsDC = true
+			return
+		}
+	}
+
+	// 检查代理
+	for _, keyword := range proxyKeywords {
+		if strings.Contains(org, keyword) || strings.Contains(asn, keyword) {
+			ipInfo.IPType = "代理IP"
+			ipInfo.IsProxy = true
+			return
+		}
+	}
+
+	// 检查移动网络
+	for _, keyword := range mobileKeywords {
+		if strings.Contains(org, keyword) || strings.Contains(asn, keyword) {
+			ipInfo.IPType = "移动网络IP"
+			return
+		}
+	}
+
+	// 检查家庭宽带
+	for _, keyword := range homeKeywords {
+		if strings.Contains(org, keyword) || strings.Contains(asn, keyword) {
+			ipInfo.IPType = "家庭宽带IP"
+			return
+		}
+	}
+
+	// 默认为家庭宽带IP，如果没有匹配到任何关键词
+	ipInfo.IPType = "家庭宽带IP"
+}
+
+// DetermineIPPurity 判断IP纯净度
+func DetermineIPPurity(ipInfo *model.IPInfo) {
+	// 默认分数为100（满分）
+	ipInfo.PureScore = 100
+
+	// 根据IP类型给予不同的基础分数
+	switch ipInfo.IPType {
+	case "家庭宽带IP":
+		// 家庭宽带IP通常是最纯净的，保持100分
+	case "移动网络IP":
+		// 移动网络IP可能会有一些共享问题，轻微扣分
+		ipInfo.PureScore -= 5
+	case "数据中心IP":
+		// 数据中心IP通常用于服务器托管，扣20分
+		ipInfo.PureScore -= 20
+		ipInfo.IsDC = true
+	case "代理IP":
+		// 代理IP通常被用于隐藏真实身份，扣50分
+		ipInfo.PureScore -= 50
+		ipInfo.IsProxy = true
+	case "未知":
+		// 未知类型扣10分
+		ipInfo.PureScore -= 10
+	}
+
+	// 如果明确标记为代理IP，无论IP类型如何，都扣除50分
+	if ipInfo.IsProxy && ipInfo.IPType != "代理IP" {
+		ipInfo.PureScore -= 50
+	}
+
+	// 如果明确标记为数据中心IP，无论IP类型如何，都扣除20分
+	if ipInfo.IsDC && ipInfo.IPType != "数据中心IP" {
+		ipInfo.PureScore -= 20
+	}
+
+	// 确保分数在0-100范围内
+	if ipInfo.PureScore < 0 {
+		ipInfo.PureScore = 0
+	}
+	if ipInfo.PureScore > 100 {
+		ipInfo.PureScore = 100
+	}
+
+	// 根据分数确定纯净度类型
+	if ipInfo.PureScore >= 90 {
+		ipInfo.PureType = "优质"
+		ipInfo.IsPure = true
+	} else if ipInfo.PureScore >= 70 {
+		ipInfo.PureType = "良好"
+		ipInfo.IsPure = true
+	} else if ipInfo.PureScore >= 50 {
+		ipInfo.PureType = "一般"
+		ipInfo.IsPure = false
+	} else {
+		ipInfo.PureType = "较差"
+		ipInfo.IsPure = false
+	}
+}
+
+// CompleteIPInfo 设置API源并补全版本、网络、IP类型和纯净度等派生字段
+func CompleteIPInfo(ipInfo *model.IPInfo, source string) *model.IPInfo {
+	// 设置API源
+	ipInfo.APISource = source
+
+	// 补全部分API源不返回的版本和网络信息
+	if ipInfo.Version == "" {
+		ipInfo.Version = getIPVersion(ipInfo.IP)
+	}
+	if ipInfo.Network == "" {
+		ipInfo.Network = getNetworkFromIP(ipInfo.IP)
+	}
+
+	// 判断IP类型和纯净度
+	DetermineIPType(ipInfo)
+	DetermineIPPurity(ipInfo)
+
+	return ipInfo
+}
@@ -0,0 +1,142 @@
+package myip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/oschwald/geoip2-golang"
+)
+
+// MMDBProviderName 离线数据库提供者的名称
+const MMDBProviderName = "mmdb"
+
+// ErrNoRecord 离线数据库中没有要查询的IP的记录
+var ErrNoRecord = errors.New("离线数据库中没有该IP的记录")
+
+// LocalProvider 无需发送HTTP请求即可查询的提供者（例如离线数据库）
+// Client 遇到实现该接口的提供者时直接调用 Lookup
+type LocalProvider interface {
+	Provider
+	// Lookup 查询指定IP的信息
+	Lookup(ip string) (*model.IPInfo, error)
+}
+
+// mmdbProvider 基于MaxMind GeoLite2/DB-IP .mmdb文件的离线提供者
+type mmdbProvider struct {
+	city *geoip2.Reader // City或Country数据库
+	asn  *geoip2.Reader // ASN数据库
+}
+
+// NewMMDBProvider 打开一个或多个.mmdb文件创建离线提供者
+// 根据数据库元数据自动识别City/Country库和ASN库
+func NewMMDBProvider(paths ...string) (LocalProvider, error) {
+	provider := &mmdbProvider{}
+	for _, path := range paths {
+		reader, err := geoip2.Open(path)
+		if err != nil {
+			provider.Close()
+			return nil, fmt.Errorf("无法打开离线数据库 %s: %v", path, err)
+		}
+
+		dbType := reader.Metadata().DatabaseType
+		switch {
+		case strings.Contains(dbType, "ASN"):
+			provider.asn = reader
+		case strings.Contains(dbType, "City"), strings.Contains(dbType, "Country"):
+			provider.city = reader
+		default:
+			reader.Close()
+			provider.Close()
+			return nil, fmt.Errorf("不支持的离线数据库类型 %s: %s", dbType, path)
+		}
+	}
+
+	if provider.city == nil && provider.asn == nil {
+		return nil, errors.New("未指定离线数据库文件")
+	}
+	return provider, nil
+}
+
+func (p *mmdbProvider) Name() string { return MMDBProviderName }
+
+func (p *mmdbProvider) NewRequest(ip string) (*http.Request, error) {
+	return nil, errors.New("离线数据库不发送HTTP请求")
+}
+
+func (p *mmdbProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return nil, errors.New("离线数据库不解析HTTP响应")
+}
+
+func (p *mmdbProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true}
+}
+
+// Lookup 从离线数据库查询IP信息
+func (p *mmdbProvider) Lookup(ip string) (*model.IPInfo, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("离线数据库需要合法的IP地址: %q", ip)
+	}
+
+	info := &model.IPInfo{IP: parsed.String()}
+	found := false
+
+	if p.city != nil {
+		record, err := p.city.City(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("查询离线数据库失败: %w", err)
+		}
+		if record.Country.IsoCode != "" {
+			found = true
+			info.City = record.City.Names["en"]
+			if len(record.Subdivisions) > 0 {
+				info.Region = record.Subdivisions[0].Names["en"]
+				info.RegionCode = record.Subdivisions[0].IsoCode
+			}
+			info.Country = record.Country.IsoCode
+			info.CountryCode = record.Country.IsoCode
+			info.CountryName = record.Country.Names["en"]
+			info.InEU = record.Country.IsInEuropeanUnion
+			info.ContinentCode = record.Continent.Code
+			info.Postal = record.Postal.Code
+			info.Latitude = record.Location.Latitude
+			info.Longitude = record.Location.Longitude
+			info.Timezone = record.Location.TimeZone
+
+			// 设置货币和通信区号
+			info.Currency, info.CurrencyName = getCurrencyInfo(info.CountryCode)
+			info.CallingCode = getCallingCode(info.CountryCode)
+		}
+	}
+
+	if p.asn != nil {
+		record, err := p.asn.ASN(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("查询离线数据库失败: %w", err)
+		}
+		if record.AutonomousSystemNumber != 0 {
+			found = true
+			info.ASN = fmt.Sprintf("AS%d", record.AutonomousSystemNumber)
+			info.Org = record.AutonomousSystemOrganization
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNoRecord, ip)
+	}
+	return info, nil
+}
+
+// Close 关闭打开的数据库
+func (p *mmdbProvider) Close() {
+	if p.city != nil {
+		p.city.Close()
+	}
+	if p.asn != nil {
+		p.asn.Close()
+	}
+}
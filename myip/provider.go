@@ -0,0 +1,184 @@
+// Package myip 提供获取公网IP、查询IP详细信息、列出本地网络接口和测试站点连通性的功能，
+// 命令行工具和其他Go程序都可以直接使用
+package myip
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/cikichen/MyIp/model"
+)
+
+// Capabilities 描述IP信息提供者支持的能力
+type Capabilities struct {
+	IPv6       bool // 支持查询IPv6地址
+	SelfLookup bool // 支持不传IP直接查询请求方的公网IP
+	Proxy      bool // 响应中包含代理/VPN标识
+	Hosting    bool // 响应中包含数据中心/托管标识
+	// NeedsAPIKey 必须配置API密钥才能使用但尚未配置，默认的提供者列表会跳过这类提供者
+	NeedsAPIKey bool
+}
+
+// Provider IP信息提供者接口
+// 实现该接口后即可添加到 Client.Providers，命令行工具中通过 cmd.RegisterProvider 注册
+type Provider interface {
+	// Name 返回提供者名称，用于选择提供者和 APISource 字段
+	Name() string
+	// NewRequest 构建查询指定IP的HTTP请求，ip为空表示查询请求方自身
+	NewRequest(ip string) (*http.Request, error)
+	// Parse 将响应内容解析为IPInfo
+	Parse(data []byte) (*model.IPInfo, error)
+	// Capabilities 返回提供者支持的能力
+	Capabilities() Capabilities
+}
+
+// AuthProvider 支持API密钥的提供者
+type AuthProvider interface {
+	Provider
+	// WithAPIKey 返回使用指定API密钥的提供者
+	WithAPIKey(key string) Provider
+}
+
+// DefaultProviders 返回内置的在线IP信息提供者，顺序即默认的故障转移顺序
+func DefaultProviders() []Provider {
+	return []Provider{
+		ipapiProvider{},
+		ipinfoProvider{},
+		ipwhoisProvider{},
+		ipapiComProvider{},
+		ifconfigProvider{},
+		ipgeolocationProvider{},
+	}
+}
+
+// UsableProviders 过滤掉必须配置API密钥但尚未配置的提供者
+func UsableProviders(providers []Provider) []Provider {
+	usable := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		if !p.Capabilities().NeedsAPIKey {
+			usable = append(usable, p)
+		}
+	}
+	return usable
+}
+
+// StatusError 提供者返回了非200的HTTP状态码
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration // 响应中 Retry-After 头指定的等待时间
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s 返回HTTP %d", e.Provider, e.StatusCode)
+}
+
+// NewStatusError 根据响应创建 StatusError，解析秒数或HTTP日期格式的 Retry-After 头
+func NewStatusError(provider string, resp *http.Response) *StatusError {
+	err := &StatusError{Provider: provider, StatusCode: resp.StatusCode}
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, parseErr := strconv.Atoi(value); parseErr == nil {
+			err.RetryAfter = time.Duration(seconds) * time.Second
+		} else if t, parseErr := http.ParseTime(value); parseErr == nil {
+			err.RetryAfter = time.Until(t)
+		}
+	}
+	return err
+}
+
+const (
+	// userAgent 使用API密钥时发送的User-Agent
+	userAgent = "MyIp (+https://github.com/cikichen/MyIp)"
+	// browserUserAgent 未使用API密钥时模拟浏览器的User-Agent
+	browserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+)
+
+// newProviderRequest 创建带通用请求头的GET请求
+func newProviderRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置请求头，模拟浏览器请求
+	req.Header.Set("User-Agent", browserUserAgent)
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// newAuthRequest 创建带API密钥的请求，如实标识客户端，不再模拟浏览器
+func newAuthRequest(url string) (*http.Request, error) {
+	req, err := newProviderRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
+// ipapiProvider ipapi.co 提供者，key 为空时匿名查询
+type ipapiProvider struct {
+	key string
+}
+
+func (ipapiProvider) Name() string { return "ipapi.co" }
+
+func (p ipapiProvider) NewRequest(ip string) (*http.Request, error) {
+	url := "https://ipapi.co/json/"
+	if ip != "" {
+		url = "https://ipapi.co/" + ip + "/json/"
+	}
+	if p.key == "" {
+		return newProviderRequest(url)
+	}
+	// ipapi.co 通过查询参数传递密钥
+	return newAuthRequest(url + "?key=" + neturl.QueryEscape(p.key))
+}
+
+func (ipapiProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return parseIpapiResponse(data)
+}
+
+func (ipapiProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true, SelfLookup: true}
+}
+
+func (ipapiProvider) WithAPIKey(key string) Provider { return ipapiProvider{key: key} }
+
+// ipinfoProvider ipinfo.io 提供者，token 为空时匿名查询
+type ipinfoProvider struct {
+	token string
+}
+
+func (ipinfoProvider) Name() string { return "ipinfo.io" }
+
+func (p ipinfoProvider) NewRequest(ip string) (*http.Request, error) {
+	url := "https://ipinfo.io/json"
+	if ip != "" {
+		url = "https://ipinfo.io/" + ip + "/json"
+	}
+	if p.token == "" {
+		return newProviderRequest(url)
+	}
+	// ipinfo.io 通过Bearer令牌认证
+	req, err := newAuthRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return req, nil
+}
+
+func (ipinfoProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return parseIpinfoResponse(data)
+}
+
+// Capabilities 带令牌时响应中包含 privacy 字段（取决于订阅套餐）
+func (p ipinfoProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true, SelfLookup: true, Proxy: p.token != "", Hosting: p.token != ""}
+}
+
+func (ipinfoProvider) WithAPIKey(token string) Provider { return ipinfoProvider{token: token} }
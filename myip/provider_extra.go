@@ -0,0 +1,425 @@
+package myip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cikichen/MyIp/model"
+)
+
+// ipwhoisProvider ipwho.is 提供者，key 为空时使用免费接口
+type ipwhoisProvider struct {
+	key string
+}
+
+func (ipwhoisProvider) Name() string { return "ipwho.is" }
+
+func (p ipwhoisProvider) NewRequest(ip string) (*http.Request, error) {
+	url := "https://ipwho.is/" + ip
+	if p.key == "" {
+		return newProviderRequest(url)
+	}
+	return newAuthRequest(url + "?key=" + neturl.QueryEscape(p.key))
+}
+
+func (ipwhoisProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return parseIpwhoisResponse(data)
+}
+
+// Capabilities 付费套餐的响应中包含 security 字段
+func (p ipwhoisProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true, SelfLookup: true, Proxy: p.key != "", Hosting: p.key != "", NeedsAPIKey: p.key == ""}
+}
+
+func (ipwhoisProvider) WithAPIKey(key string) Provider { return ipwhoisProvider{key: key} }
+
+// ipapiComProvider ip-api.com 提供者，免费接口仅支持HTTP，使用密钥时访问 pro.ip-api.com
+type ipapiComProvider struct {
+	key string
+}
+
+// ipapiComFields ip-api.com 返回的字段列表
+const ipapiComFields = "status,message,continentCode,country,countryCode,region,regionName,city,zip,lat,lon,timezone,offset,currency,isp,org,as,asname,mobile,proxy,hosting,query"
+
+func (ipapiComProvider) Name() string { return "ip-api.com" }
+
+func (p ipapiComProvider) NewRequest(ip string) (*http.Request, error) {
+	if p.key == "" {
+		return newProviderRequest("http://ip-api.com/json/" + ip + "?fields=" + ipapiComFields)
+	}
+	return newAuthRequest("https://pro.ip-api.com/json/" + ip + "?fields=" + ipapiComFields + "&key=" + neturl.QueryEscape(p.key))
+}
+
+func (ipapiComProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return parseIpapiComResponse(data)
+}
+
+func (ipapiComProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true, SelfLookup: true, Proxy: true, Hosting: true}
+}
+
+func (ipapiComProvider) WithAPIKey(key string) Provider { return ipapiComProvider{key: key} }
+
+// ipgeolocationProvider ipgeolocation.io 提供者，必须配置API密钥
+type ipgeolocationProvider struct {
+	key string
+}
+
+func (ipgeolocationProvider) Name() string { return "ipgeolocation.io" }
+
+func (p ipgeolocationProvider) NewRequest(ip string) (*http.Request, error) {
+	if p.key == "" {
+		return nil, fmt.Errorf("ipgeolocation.io %w (api_keys.ipgeolocation)", ErrAPIKeyRequired)
+	}
+	query := neturl.Values{}
+	query.Set("apiKey", p.key)
+	query.Set("include", "security")
+	if ip != "" {
+		query.Set("ip", ip)
+	}
+	return newAuthRequest("https://api.ipgeolocation.io/ipgeo?" + query.Encode())
+}
+
+func (ipgeolocationProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return parseIpgeolocationResponse(data)
+}
+
+// Capabilities security 字段需要付费套餐
+func (p ipgeolocationProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true, SelfLookup: true, Proxy: p.key != "", Hosting: p.key != ""}
+}
+
+func (ipgeolocationProvider) WithAPIKey(key string) Provider { return ipgeolocationProvider{key: key} }
+
+// ifconfigProvider ifconfig.co JSON接口提供者
+type ifconfigProvider struct{}
+
+func (ifconfigProvider) Name() string { return "ifconfig.co" }
+
+func (ifconfigProvider) NewRequest(ip string) (*http.Request, error) {
+	if ip == "" {
+		return newProviderRequest("https://ifconfig.co/json")
+	}
+	return newProviderRequest("https://ifconfig.co/json?ip=" + neturl.QueryEscape(ip))
+}
+
+func (ifconfigProvider) Parse(data []byte) (*model.IPInfo, error) {
+	return parseIfconfigResponse(data)
+}
+
+func (ifconfigProvider) Capabilities() Capabilities {
+	return Capabilities{IPv6: true, SelfLookup: true}
+}
+
+// parseIpwhoisResponse 解析ipwho.is的响应
+func parseIpwhoisResponse(data []byte) (*model.IPInfo, error) {
+	var response struct {
+		IP            string  `json:"ip"`
+		Success       bool    `json:"success"`
+		Message       string  `json:"message"`
+		Type          string  `json:"type"`
+		ContinentCode string  `json:"continent_code"`
+		Country       string  `json:"country"`
+		CountryCode   string  `json:"country_code"`
+		Region        string  `json:"region"`
+		RegionCode    string  `json:"region_code"`
+		City          string  `json:"city"`
+		Latitude      float64 `json:"latitude"`
+		Longitude     float64 `json:"longitude"`
+		IsEU          bool    `json:"is_eu"`
+		Postal        string  `json:"postal"`
+		CallingCode   string  `json:"calling_code"`
+		Capital       string  `json:"capital"`
+		Connection    struct {
+			ASN    int    `json:"asn"`
+			Org    string `json:"org"`
+			ISP    string `json:"isp"`
+			Domain string `json:"domain"`
+		} `json:"connection"`
+		Timezone struct {
+			ID  string `json:"id"`
+			UTC string `json:"utc"`
+		} `json:"timezone"`
+		// 付费套餐才返回
+		Security *struct {
+			Anonymous bool `json:"anonymous"`
+			Proxy     bool `json:"proxy"`
+			VPN       bool `json:"vpn"`
+			Tor       bool `json:"tor"`
+			Hosting   bool `json:"hosting"`
+		} `json:"security"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析ipwho.is响应失败: %v", err)
+	}
+	if !response.Success {
+		return nil, fmt.Errorf("ipwho.is返回错误: %s", response.Message)
+	}
+	if response.IP == "" {
+		return nil, fmt.Errorf("ipwho.is响应缺少IP字段")
+	}
+
+	ipInfo := &model.IPInfo{
+		IP:             response.IP,
+		Version:        response.Type,
+		City:           response.City,
+		Region:         response.Region,
+		RegionCode:     response.RegionCode,
+		Country:        response.CountryCode,
+		CountryName:    response.Country,
+		CountryCode:    response.CountryCode,
+		CountryCapital: response.Capital,
+		ContinentCode:  response.ContinentCode,
+		InEU:           response.IsEU,
+		Postal:         response.Postal,
+		Latitude:       response.Latitude,
+		Longitude:      response.Longitude,
+		Timezone:       response.Timezone.ID,
+		UTCOffset:      strings.Replace(response.Timezone.UTC, ":", "", 1),
+		Org:            response.Connection.ISP,
+		ASNDomain:      response.Connection.Domain,
+	}
+	if ipInfo.Org == "" {
+		ipInfo.Org = response.Connection.Org
+	}
+	if response.Connection.ASN != 0 {
+		ipInfo.ASN = fmt.Sprintf("AS%d", response.Connection.ASN)
+	}
+	if response.CallingCode != "" {
+		ipInfo.CallingCode = "+" + strings.TrimPrefix(response.CallingCode, "+")
+	}
+	if response.Security != nil {
+		ipInfo.PrivacyVPN = response.Security.VPN
+		ipInfo.PrivacyProxy = response.Security.Proxy || response.Security.Anonymous
+		ipInfo.PrivacyTor = response.Security.Tor
+		ipInfo.PrivacyHosting = response.Security.Hosting
+	}
+	ipInfo.Currency, ipInfo.CurrencyName = getCurrencyInfo(ipInfo.CountryCode)
+
+	return ipInfo, nil
+}
+
+// parseIpapiComResponse 解析ip-api.com的响应
+func parseIpapiComResponse(data []byte) (*model.IPInfo, error) {
+	var response struct {
+		Status        string  `json:"status"`
+		Message       string  `json:"message"`
+		Query         string  `json:"query"`
+		ContinentCode string  `json:"continentCode"`
+		Country       string  `json:"country"`
+		CountryCode   string  `json:"countryCode"`
+		Region        string  `json:"region"`
+		RegionName    string  `json:"regionName"`
+		City          string  `json:"city"`
+		Zip           string  `json:"zip"`
+		Lat           float64 `json:"lat"`
+		Lon           float64 `json:"lon"`
+		Timezone      string  `json:"timezone"`
+		Offset        int     `json:"offset"`
+		Currency      string  `json:"currency"`
+		ISP           string  `json:"isp"`
+		Org           string  `json:"org"`
+		AS            string  `json:"as"`
+		ASName        string  `json:"asname"`
+		Mobile        bool    `json:"mobile"`
+		Proxy         bool    `json:"proxy"`
+		Hosting       bool    `json:"hosting"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析ip-api.com响应失败: %v", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("ip-api.com返回错误: %s", response.Message)
+	}
+	if response.Query == "" {
+		return nil, fmt.Errorf("ip-api.com响应缺少IP字段")
+	}
+
+	ipInfo := &model.IPInfo{
+		IP:             response.Query,
+		City:           response.City,
+		Region:         response.RegionName,
+		RegionCode:     response.Region,
+		Country:        response.CountryCode,
+		CountryName:    response.Country,
+		CountryCode:    response.CountryCode,
+		ContinentCode:  response.ContinentCode,
+		Postal:         response.Zip,
+		Latitude:       response.Lat,
+		Longitude:      response.Lon,
+		Timezone:       response.Timezone,
+		UTCOffset:      formatUTCOffset(response.Offset),
+		Currency:       response.Currency,
+		CallingCode:    getCallingCode(response.CountryCode),
+		Org:            response.ISP,
+		CompanyName:    response.Org,
+		PrivacyProxy:   response.Proxy,
+		PrivacyHosting: response.Hosting,
+	}
+	if ipInfo.Org == "" {
+		ipInfo.Org = response.ASName
+	}
+
+	// as 字段格式为 "AS15169 Google LLC"
+	if parts := strings.SplitN(response.AS, " ", 2); strings.HasPrefix(parts[0], "AS") {
+		ipInfo.ASN = parts[0]
+	}
+	if response.Mobile {
+		ipInfo.ASNType = "mobile"
+	}
+	_, ipInfo.CurrencyName = getCurrencyInfo(response.CountryCode)
+
+	return ipInfo, nil
+}
+
+// parseIpgeolocationResponse 解析ipgeolocation.io的响应
+func parseIpgeolocationResponse(data []byte) (*model.IPInfo, error) {
+	var response struct {
+		IP            string `json:"ip"`
+		Message       string `json:"message"`
+		ContinentCode string `json:"continent_code"`
+		CountryCode2  string `json:"country_code2"`
+		CountryCode3  string `json:"country_code3"`
+		CountryName   string `json:"country_name"`
+		CountryCap    string `json:"country_capital"`
+		StateProv     string `json:"state_prov"`
+		StateCode     string `json:"state_code"`
+		City          string `json:"city"`
+		Zipcode       string `json:"zipcode"`
+		Latitude      string `json:"latitude"`
+		Longitude     string `json:"longitude"`
+		IsEU          bool   `json:"is_eu"`
+		CallingCode   string `json:"calling_code"`
+		CountryTLD    string `json:"country_tld"`
+		Languages     string `json:"languages"`
+		ISP           string `json:"isp"`
+		Organization  string `json:"organization"`
+		ASN           string `json:"asn"`
+		Currency      struct {
+			Code string `json:"code"`
+			Name string `json:"name"`
+		} `json:"currency"`
+		TimeZone struct {
+			Name   string  `json:"name"`
+			Offset float64 `json:"offset"`
+		} `json:"time_zone"`
+		Security *struct {
+			IsTor           bool `json:"is_tor"`
+			IsProxy         bool `json:"is_proxy"`
+			IsAnonymous     bool `json:"is_anonymous"`
+			IsCloudProvider bool `json:"is_cloud_provider"`
+		} `json:"security"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析ipgeolocation.io响应失败: %v", err)
+	}
+	if response.IP == "" {
+		if response.Message != "" {
+			return nil, fmt.Errorf("ipgeolocation.io返回错误: %s", response.Message)
+		}
+		return nil, fmt.Errorf("ipgeolocation.io响应缺少IP字段")
+	}
+
+	ipInfo := &model.IPInfo{
+		IP:             response.IP,
+		City:           response.City,
+		Region:         response.StateProv,
+		RegionCode:     response.StateCode,
+		Country:        response.CountryCode2,
+		CountryName:    response.CountryName,
+		CountryCode:    response.CountryCode2,
+		CountryCodeISO: response.CountryCode3,
+		CountryCapital: response.CountryCap,
+		CountryTLD:     response.CountryTLD,
+		ContinentCode:  response.ContinentCode,
+		InEU:           response.IsEU,
+		Postal:         response.Zipcode,
+		Timezone:       response.TimeZone.Name,
+		UTCOffset:      formatUTCOffset(int(response.TimeZone.Offset * 3600)),
+		CallingCode:    response.CallingCode,
+		Currency:       response.Currency.Code,
+		CurrencyName:   response.Currency.Name,
+		Languages:      response.Languages,
+		ASN:            response.ASN,
+		Org:            response.ISP,
+		CompanyName:    response.Organization,
+	}
+	ipInfo.Latitude, _ = strconv.ParseFloat(response.Latitude, 64)
+	ipInfo.Longitude, _ = strconv.ParseFloat(response.Longitude, 64)
+	if response.Security != nil {
+		ipInfo.PrivacyTor = response.Security.IsTor
+		ipInfo.PrivacyProxy = response.Security.IsProxy || response.Security.IsAnonymous
+		ipInfo.PrivacyHosting = response.Security.IsCloudProvider
+	}
+
+	return ipInfo, nil
+}
+
+// parseIfconfigResponse 解析ifconfig.co的JSON响应
+func parseIfconfigResponse(data []byte) (*model.IPInfo, error) {
+	var response struct {
+		IP         string  `json:"ip"`
+		Country    string  `json:"country"`
+		CountryISO string  `json:"country_iso"`
+		CountryEU  bool    `json:"country_eu"`
+		RegionName string  `json:"region_name"`
+		RegionCode string  `json:"region_code"`
+		ZipCode    string  `json:"zip_code"`
+		City       string  `json:"city"`
+		Latitude   float64 `json:"latitude"`
+		Longitude  float64 `json:"longitude"`
+		TimeZone   string  `json:"time_zone"`
+		ASN        string  `json:"asn"`
+		ASNOrg     string  `json:"asn_org"`
+		Error      string  `json:"error"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析ifconfig.co响应失败: %v", err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("ifconfig.co返回错误: %s", response.Error)
+	}
+	if response.IP == "" {
+		return nil, fmt.Errorf("ifconfig.co响应缺少IP字段")
+	}
+
+	ipInfo := &model.IPInfo{
+		IP:            response.IP,
+		City:          response.City,
+		Region:        response.RegionName,
+		RegionCode:    response.RegionCode,
+		Country:       response.CountryISO,
+		CountryName:   response.Country,
+		CountryCode:   response.CountryISO,
+		ContinentCode: getContinentCode(response.CountryISO),
+		InEU:          response.CountryEU,
+		Postal:        response.ZipCode,
+		Latitude:      response.Latitude,
+		Longitude:     response.Longitude,
+		Timezone:      response.TimeZone,
+		CallingCode:   getCallingCode(response.CountryISO),
+		ASN:           response.ASN,
+		Org:           response.ASNOrg,
+	}
+	ipInfo.Currency, ipInfo.CurrencyName = getCurrencyInfo(response.CountryISO)
+
+	return ipInfo, nil
+}
+
+// formatUTCOffset 将以秒为单位的时区偏移格式化为 +0800 形式
+func formatUTCOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
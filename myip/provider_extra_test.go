@@ -0,0 +1,201 @@
+package myip
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cikichen/MyIp/model"
+)
+
+// parserCase 使用 testdata 中录制的响应测试解析函数
+type parserCase struct {
+	file     string       // testdata 中的响应文件，body 不为空时忽略
+	body     string       // 直接指定的响应内容
+	want     model.IPInfo // 期望的字段，只比较 checkIPInfo 中列出的字段
+	wantErr  string       // 期望错误信息包含的内容
+	wantType string       // DetermineIPType 之后期望的IP类型，为空时不检查
+}
+
+// runParserCases 依次解析每个用例的响应并检查结果
+func runParserCases(t *testing.T, parse func([]byte) (*model.IPInfo, error), cases []parserCase) {
+	t.Helper()
+	for _, tc := range cases {
+		name := tc.file
+		if name == "" {
+			name = tc.body
+		}
+		t.Run(name, func(t *testing.T) {
+			data := []byte(tc.body)
+			if tc.body == "" {
+				var err error
+				data, err = os.ReadFile(filepath.Join("testdata", tc.file))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			info, err := parse(data)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, 期望包含 %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			checkIPInfo(t, info, &tc.want)
+
+			if tc.wantType != "" {
+				DetermineIPType(info)
+				if info.IPType != tc.wantType {
+					t.Errorf("IPType = %q, 期望 %q", info.IPType, tc.wantType)
+				}
+			}
+		})
+	}
+}
+
+// checkIPInfo 比较解析结果的主要字段
+func checkIPInfo(t *testing.T, got, want *model.IPInfo) {
+	t.Helper()
+	strs := []struct {
+		name      string
+		got, want string
+	}{
+		{"IP", got.IP, want.IP},
+		{"City", got.City, want.City},
+		{"Region", got.Region, want.Region},
+		{"RegionCode", got.RegionCode, want.RegionCode},
+		{"CountryCode", got.CountryCode, want.CountryCode},
+		{"CountryName", got.CountryName, want.CountryName},
+		{"ContinentCode", got.ContinentCode, want.ContinentCode},
+		{"Postal", got.Postal, want.Postal},
+		{"Timezone", got.Timezone, want.Timezone},
+		{"UTCOffset", got.UTCOffset, want.UTCOffset},
+		{"CallingCode", got.CallingCode, want.CallingCode},
+		{"Currency", got.Currency, want.Currency},
+		{"ASN", got.ASN, want.ASN},
+		{"ASNType", got.ASNType, want.ASNType},
+		{"Org", got.Org, want.Org},
+		{"CompanyName", got.CompanyName, want.CompanyName},
+	}
+	for _, f := range strs {
+		if f.got != f.want {
+			t.Errorf("%s = %q, 期望 %q", f.name, f.got, f.want)
+		}
+	}
+
+	if got.Latitude != want.Latitude || got.Longitude != want.Longitude {
+		t.Errorf("坐标 = %v,%v, 期望 %v,%v", got.Latitude, got.Longitude, want.Latitude, want.Longitude)
+	}
+
+	bools := []struct {
+		name      string
+		got, want bool
+	}{
+		{"InEU", got.InEU, want.InEU},
+		{"PrivacyProxy", got.PrivacyProxy, want.PrivacyProxy},
+		{"PrivacyTor", got.PrivacyTor, want.PrivacyTor},
+		{"PrivacyHosting", got.PrivacyHosting, want.PrivacyHosting},
+	}
+	for _, f := range bools {
+		if f.got != f.want {
+			t.Errorf("%s = %v, 期望 %v", f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestParseIpwhoisResponse(t *testing.T) {
+	runParserCases(t, parseIpwhoisResponse, []parserCase{
+		{
+			file: "ipwhois_success.json",
+			want: model.IPInfo{
+				IP: "8.8.8.8", City: "Mountain View", Region: "California", RegionCode: "CA",
+				CountryCode: "US", CountryName: "United States", ContinentCode: "NA", Postal: "94039",
+				Timezone: "America/Los_Angeles", UTCOffset: "-0700", CallingCode: "+1", Currency: "USD",
+				ASN: "AS15169", Org: "Google LLC", Latitude: 37.3860517, Longitude: -122.0838511,
+			},
+		},
+		{
+			file: "ipwhois_security.json",
+			want: model.IPInfo{
+				IP: "185.220.101.1", City: "Brandenburg", Region: "Brandenburg", RegionCode: "BB",
+				CountryCode: "DE", CountryName: "Germany", ContinentCode: "EU", Postal: "14776",
+				Timezone: "Europe/Berlin", UTCOffset: "+0200", CallingCode: "+49", Currency: "EUR",
+				ASN: "AS60729", Org: "Stiftung Erneuerbare Freiheit", Latitude: 52.4125287, Longitude: 12.5316444,
+				InEU: true, PrivacyProxy: true, PrivacyTor: true,
+			},
+			wantType: "代理IP",
+		},
+		{file: "ipwhois_fail.json", wantErr: "Invalid IP address"},
+		{body: `{"success":true}`, wantErr: "缺少IP字段"},
+		{body: `<html>502 Bad Gateway</html>`, wantErr: "解析ipwho.is响应失败"},
+	})
+}
+
+func TestParseIpapiComResponse(t *testing.T) {
+	runParserCases(t, parseIpapiComResponse, []parserCase{
+		{
+			file: "ipapicom_success.json",
+			want: model.IPInfo{
+				IP: "8.8.8.8", City: "Ashburn", Region: "Virginia", RegionCode: "VA",
+				CountryCode: "US", CountryName: "United States", ContinentCode: "NA", Postal: "20149",
+				Timezone: "America/New_York", UTCOffset: "-0400", CallingCode: "+1", Currency: "USD",
+				ASN: "AS15169", Org: "Google LLC", CompanyName: "Google Public DNS",
+				Latitude: 39.03, Longitude: -77.5, PrivacyHosting: true,
+			},
+			wantType: "数据中心IP",
+		},
+		{
+			file: "ipapicom_mobile.json",
+			want: model.IPInfo{
+				IP: "166.137.1.1", City: "Dallas", Region: "Texas", RegionCode: "TX",
+				CountryCode: "US", CountryName: "United States", ContinentCode: "NA", Postal: "75201",
+				Timezone: "America/Chicago", UTCOffset: "-0500", CallingCode: "+1", Currency: "USD",
+				ASN: "AS20057", ASNType: "mobile", Org: "AT&T Mobility LLC",
+				Latitude: 32.7767, Longitude: -96.797,
+			},
+			wantType: "移动网络IP",
+		},
+		{file: "ipapicom_fail.json", wantErr: "private range"},
+		{body: `not json`, wantErr: "解析ip-api.com响应失败"},
+	})
+}
+
+func TestParseIpgeolocationResponse(t *testing.T) {
+	runParserCases(t, parseIpgeolocationResponse, []parserCase{
+		{
+			file: "ipgeolocation_success.json",
+			want: model.IPInfo{
+				IP: "8.8.8.8", City: "Mountain View", Region: "California", RegionCode: "US-CA",
+				CountryCode: "US", CountryName: "United States", ContinentCode: "NA", Postal: "94043-1351",
+				Timezone: "America/Los_Angeles", UTCOffset: "-0800", CallingCode: "+1", Currency: "USD",
+				ASN: "AS15169", Org: "Google LLC", CompanyName: "Google LLC",
+				Latitude: 37.4224, Longitude: -122.08421, PrivacyHosting: true,
+			},
+			wantType: "数据中心IP",
+		},
+		{file: "ipgeolocation_invalid_key.json", wantErr: "API key is not valid"},
+		{body: `{}`, wantErr: "缺少IP字段"},
+		{body: `[]`, wantErr: "解析ipgeolocation.io响应失败"},
+	})
+}
+
+func TestParseIfconfigResponse(t *testing.T) {
+	runParserCases(t, parseIfconfigResponse, []parserCase{
+		{
+			file: "ifconfig_success.json",
+			want: model.IPInfo{
+				IP: "8.8.8.8", CountryCode: "US", CountryName: "United States", ContinentCode: "NA",
+				Timezone: "America/Chicago", CallingCode: "+1", Currency: "USD",
+				ASN: "AS15169", Org: "GOOGLE", Latitude: 37.751, Longitude: -97.822,
+			},
+		},
+		{file: "ifconfig_error.json", wantErr: "ifconfig.co返回错误: invalid IP address: 999.1.1.1"},
+		{body: `{}`, wantErr: "缺少IP字段"},
+		{body: `404 page not found`, wantErr: "解析ifconfig.co响应失败"},
+	})
+}
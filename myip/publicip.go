@@ -0,0 +1,108 @@
+package myip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPublicIPSources 获取公网IP的默认API源
+// 这些API源按优先级排序，程序会按顺序尝试，直到成功获取IP
+// 所有源都支持双栈，配合强制tcp4/tcp6拨号可分别获取IPv4和IPv6地址
+var DefaultPublicIPSources = []string{
+	"https://myexternalip.com/raw",
+	"https://api64.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+}
+
+// ErrInvalidPublicIP 公网IP源的响应不是合法的IP地址
+var ErrInvalidPublicIP = errors.New("响应不是合法的IP地址")
+
+// NewPublicIPClient 创建强制使用指定网络拨号的HTTP客户端
+// network 为 tcp4 或 tcp6 时强制使用对应协议族拨号，为 tcp 时不限制
+func NewPublicIPClient(network string, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// withNetwork 返回强制使用指定网络拨号的客户端副本
+// 克隆 client 的 *http.Transport 并包装其 DialContext，其他 RoundTripper 原样返回
+func withNetwork(client *http.Client, network string) *http.Client {
+	var base *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = t
+	default:
+		return client
+	}
+
+	dial := base.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: client.Timeout}).DialContext
+	}
+	transport := base.Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dial(ctx, network, addr)
+	}
+
+	copied := *client
+	copied.Transport = transport
+	return &copied
+}
+
+// FetchPublicIP 从单个API源获取公网IP，并校验返回内容是否为合法IP且符合协议族
+func FetchPublicIP(ctx context.Context, client *http.Client, url string, network string) (string, error) {
+	// 创建请求
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// 设置请求头，模拟浏览器请求
+	req.Header.Set("User-Agent", browserUserAgent)
+
+	// 发送请求
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", NewStatusError(url, resp)
+	}
+
+	// 读取响应，IP地址不会超过几十个字节
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+
+	// 处理响应
+	ip := strings.TrimSpace(string(content))
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPublicIP, ip)
+	}
+	isV4 := parsed.To4() != nil
+	if (network == "tcp4" && !isV4) || (network == "tcp6" && isV4) {
+		return "", fmt.Errorf("响应的IP地址 %s 与请求的协议族 %s 不符", ip, network)
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,150 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxRedirects 站点测试最多跟随的重定向次数
+const maxRedirects = 10
+
+// maxBodySize 测量内容传输时最多读取的响应体字节数
+const maxBodySize = 10 << 20
+
+// HTTPTiming 单次HTTP请求（一跳）各阶段的耗时，与 curl -w 的计时对应
+type HTTPTiming struct {
+	URL              string        // 本跳请求的URL
+	StatusCode       int           // HTTP状态码
+	Reused           bool          // 是否复用了已有连接，复用时DNS、连接和TLS耗时为0
+	DNS              time.Duration // DNS解析
+	Connect          time.Duration // TCP连接建立
+	TLSHandshake     time.Duration // TLS握手
+	ServerProcessing time.Duration // 请求发出到收到首字节
+	ContentTransfer  time.Duration // 读取响应体
+	TTFB             time.Duration // 请求开始到收到首字节
+	Total            time.Duration // 本跳总耗时
+}
+
+// traceRequest 发送一次请求并记录各阶段耗时，返回的响应体已读取并关闭
+func traceRequest(ctx context.Context, client *http.Client, rawURL string) (*HTTPTiming, *http.Response, error) {
+	// 钩子可能被并发调用（例如双栈并发拨号），甚至在 client.Do 返回后才调用，
+	// 因此所有记录都在锁内进行，结束时在锁内复制到 timing
+	var (
+		mu                                                        sync.Mutex
+		dnsStart, connectStart, tlsStart, wroteRequest, firstByte time.Time
+		dns, connect, tlsHandshake                                time.Duration
+		reused                                                    bool
+	)
+	locked := func(f func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		f()
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { locked(func() { dnsStart = time.Now() }) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			locked(func() {
+				if !dnsStart.IsZero() {
+					dns = time.Since(dnsStart)
+				}
+			})
+		},
+		ConnectStart: func(string, string) {
+			locked(func() {
+				// 双栈时可能并发连接多个地址，以第一次开始为准
+				if connectStart.IsZero() {
+					connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			locked(func() {
+				if err == nil && !connectStart.IsZero() && connect == 0 {
+					connect = time.Since(connectStart)
+				}
+			})
+		},
+		TLSHandshakeStart: func() { locked(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			locked(func() {
+				if !tlsStart.IsZero() {
+					tlsHandshake = time.Since(tlsStart)
+				}
+			})
+		},
+		GotConn:              func(info httptrace.GotConnInfo) { locked(func() { reused = info.Reused }) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { locked(func() { wroteRequest = time.Now() }) },
+		GotFirstResponseByte: func() { locked(func() { firstByte = time.Now() }) },
+	}
+
+	timing := &HTTPTiming{URL: rawURL}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", rawURL, nil)
+	if err != nil {
+		return timing, nil, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		timing.Total = time.Since(start)
+		return timing, nil, err
+	}
+	defer resp.Body.Close()
+	timing.StatusCode = resp.StatusCode
+
+	// 读取响应体以测量内容传输时间
+	_, err = io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+	end := time.Now()
+	timing.Total = end.Sub(start)
+
+	mu.Lock()
+	defer mu.Unlock()
+	timing.DNS, timing.Connect, timing.TLSHandshake, timing.Reused = dns, connect, tlsHandshake, reused
+	if !firstByte.IsZero() {
+		timing.TTFB = firstByte.Sub(start)
+		timing.ContentTransfer = end.Sub(firstByte)
+		if !wroteRequest.IsZero() {
+			timing.ServerProcessing = firstByte.Sub(wroteRequest)
+		}
+	}
+	return timing, resp, err
+}
+
+// traceRedirects 逐跳请求URL并跟随重定向，返回每一跳的耗时和最后一跳的响应
+func traceRedirects(ctx context.Context, client *http.Client, rawURL string) ([]HTTPTiming, *http.Response, error) {
+	var hops []HTTPTiming
+	for i := 0; ; i++ {
+		timing, resp, err := traceRequest(ctx, client, rawURL)
+		hops = append(hops, *timing)
+		if err != nil {
+			return hops, nil, err
+		}
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			return hops, resp, nil
+		}
+		if i >= maxRedirects {
+			return hops, resp, fmt.Errorf("重定向超过%d次", maxRedirects)
+		}
+
+		base, err := url.Parse(rawURL)
+		if err != nil {
+			return hops, resp, err
+		}
+		next, err := base.Parse(location)
+		if err != nil {
+			return hops, resp, errors.New("无效的重定向地址: " + location)
+		}
+		rawURL = next.String()
+	}
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestTraceRedirectsTLS 跟随TLS服务器的重定向并记录每一跳，使用 -race 运行时可检查钩子的数据竞争
+func TestTraceRedirectsTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	// 模拟双栈并发拨号：每次建立连接时用同一个上下文并发拨号两次，使用先完成的连接，
+	// 另一次拨号的 ConnectDone 钩子可能与其他钩子并发，甚至在 client.Do 返回后才被调用
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		type dialResult struct {
+			conn net.Conn
+			err  error
+		}
+		results := make(chan dialResult, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				results <- dialResult{conn, err}
+			}()
+		}
+		first := <-results
+		go func() {
+			if second := <-results; second.conn != nil {
+				second.conn.Close()
+			}
+		}()
+		return first.conn, first.err
+	}
+	client := &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	// 并发请求，让多个请求的钩子同时运行
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hops, resp, err := traceRedirects(context.Background(), client, srv.URL+"/a")
+			if err != nil {
+				t.Errorf("traceRedirects 失败: %v", err)
+				return
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("最终状态码 = %d, 期望 200", resp.StatusCode)
+			}
+			if len(hops) != 3 {
+				t.Errorf("len(hops) = %d, 期望 3", len(hops))
+				return
+			}
+			wantCodes := []int{http.StatusFound, http.StatusMovedPermanently, http.StatusOK}
+			for i, hop := range hops {
+				if hop.StatusCode != wantCodes[i] {
+					t.Errorf("第%d跳状态码 = %d, 期望 %d", i+1, hop.StatusCode, wantCodes[i])
+				}
+				if hop.Total <= 0 || hop.TTFB <= 0 || hop.TTFB > hop.Total {
+					t.Errorf("第%d跳 total=%v ttfb=%v", i+1, hop.Total, hop.TTFB)
+				}
+			}
+			// 第一跳必须新建连接并完成TLS握手
+			if first := hops[0]; !first.Reused && (first.Connect <= 0 || first.TLSHandshake <= 0) {
+				t.Errorf("第1跳 connect=%v tls=%v, 期望大于0", first.Connect, first.TLSHandshake)
+			}
+		}()
+	}
+	wg.Wait()
+}
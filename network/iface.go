@@ -0,0 +1,224 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// InterfaceAddr 网络接口上的一个地址
+type InterfaceAddr struct {
+	IP        string `json:"ip" yaml:"ip"`
+	PrefixLen int    `json:"prefix_len" yaml:"prefix_len"`
+	Version   string `json:"version" yaml:"version"` // IPv4/IPv6
+}
+
+// InterfaceInfo 网络接口信息
+type InterfaceInfo struct {
+	Name         string          `json:"name" yaml:"name"`
+	Index        int             `json:"index" yaml:"index"`
+	MTU          int             `json:"mtu" yaml:"mtu"`
+	HardwareAddr string          `json:"mac" yaml:"mac"`
+	Flags        []string        `json:"flags" yaml:"flags"`
+	Up           bool            `json:"up" yaml:"up"`
+	Loopback     bool            `json:"loopback" yaml:"loopback"`
+	Addrs        []InterfaceAddr `json:"addrs" yaml:"addrs"`
+	DefaultIPv4  bool            `json:"default_ipv4" yaml:"default_ipv4"` // 持有IPv4默认路由
+	DefaultIPv6  bool            `json:"default_ipv6" yaml:"default_ipv6"` // 持有IPv6默认路由
+	GatewayIPv4  string          `json:"gateway_ipv4,omitempty" yaml:"gateway_ipv4,omitempty"`
+	GatewayIPv6  string          `json:"gateway_ipv6,omitempty" yaml:"gateway_ipv6,omitempty"`
+}
+
+// String 返回地址的CIDR表示，例如 192.168.1.2/24
+func (a InterfaceAddr) String() string {
+	return a.IP + "/" + strconv.Itoa(a.PrefixLen)
+}
+
+// ListInterfaces 列出所有网络接口及其地址，并标记持有默认路由的接口
+func ListInterfaces() ([]InterfaceInfo, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	// 默认路由表读取失败（例如非Linux系统）时不标记默认路由
+	route4, hasRoute4 := readDefaultRoute(procRouteIPv4, parseDefaultRouteIPv4)
+	route6, hasRoute6 := readDefaultRoute(procRouteIPv6, parseDefaultRouteIPv6)
+
+	result := make([]InterfaceInfo, 0, len(ifaces))
+	for _, iface := range ifaces {
+		info := InterfaceInfo{
+			Name:         iface.Name,
+			Index:        iface.Index,
+			MTU:          iface.MTU,
+			HardwareAddr: iface.HardwareAddr.String(),
+			Up:           iface.Flags&net.FlagUp != 0,
+			Loopback:     iface.Flags&net.FlagLoopback != 0,
+			Addrs:        []InterfaceAddr{},
+		}
+		if iface.Flags != 0 {
+			info.Flags = strings.Split(iface.Flags.String(), "|")
+		}
+
+		if hasRoute4 && route4.Iface == iface.Name {
+			info.DefaultIPv4 = true
+			info.GatewayIPv4 = route4.Gateway
+		}
+		if hasRoute6 && route6.Iface == iface.Name {
+			info.DefaultIPv6 = true
+			info.GatewayIPv6 = route6.Gateway
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ones, _ := ipNet.Mask.Size()
+			version := "IPv6"
+			if ipNet.IP.To4() != nil {
+				version = "IPv4"
+			}
+			info.Addrs = append(info.Addrs, InterfaceAddr{
+				IP:        ipNet.IP.String(),
+				PrefixLen: ones,
+				Version:   version,
+			})
+		}
+
+		result = append(result, info)
+	}
+
+	return result, nil
+}
+
+// Linux 路由表文件
+const (
+	procRouteIPv4 = "/proc/net/route"
+	procRouteIPv6 = "/proc/net/ipv6_route"
+)
+
+// 路由标志位，定义见 linux/route.h
+const (
+	routeFlagUp     = 0x0001 // RTF_UP 路由可用
+	routeFlagReject = 0x0200 // RTF_REJECT 不可达路由
+)
+
+// defaultRoute 生效的默认路由
+type defaultRoute struct {
+	Iface   string // 出接口
+	Gateway string // 网关，直连时为空
+	Metric  uint64 // 路由优先级，越小越优先
+}
+
+// readDefaultRoute 打开路由表文件并解析生效的默认路由，文件不存在时返回false
+func readDefaultRoute(path string, parse func(io.Reader) (defaultRoute, bool)) (defaultRoute, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return defaultRoute{}, false
+	}
+	defer file.Close()
+	return parse(file)
+}
+
+// parseDefaultRouteIPv4 解析 /proc/net/route 格式的路由表，返回可用且metric最小的IPv4默认路由
+func parseDefaultRouteIPv4(r io.Reader) (defaultRoute, bool) {
+	var best defaultRoute
+	found := false
+
+	// 格式: Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+	// Flags 为十六进制，Metric 为十进制
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // 跳过表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		if fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&routeFlagUp == 0 || flags&routeFlagReject != 0 {
+			continue
+		}
+		metric, err := strconv.ParseUint(fields[6], 10, 32)
+		if err != nil {
+			continue
+		}
+		if !found || metric < best.Metric {
+			best = defaultRoute{Iface: fields[0], Gateway: parseRouteIPv4(fields[2]), Metric: metric}
+			found = true
+		}
+	}
+	return best, found
+}
+
+// parseDefaultRouteIPv6 解析 /proc/net/ipv6_route 格式的路由表，返回可用且metric最小的IPv6默认路由
+func parseDefaultRouteIPv6(r io.Reader) (defaultRoute, bool) {
+	var best defaultRoute
+	found := false
+
+	// 格式: Destination DestPrefixLen Source SourcePrefixLen NextHop Metric RefCnt Use Flags Iface
+	// Metric 和 Flags 均为十六进制
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if fields[0] != strings.Repeat("0", 32) || fields[1] != "00" {
+			continue
+		}
+		// 回环接口上的默认路由是内核添加的不可达路由
+		if fields[9] == "lo" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil || flags&routeFlagUp == 0 || flags&routeFlagReject != 0 {
+			continue
+		}
+		metric, err := strconv.ParseUint(fields[5], 16, 32)
+		if err != nil {
+			continue
+		}
+		if !found || metric < best.Metric {
+			best = defaultRoute{Iface: fields[9], Gateway: parseRouteIPv6(fields[4]), Metric: metric}
+			found = true
+		}
+	}
+	return best, found
+}
+
+// parseRouteIPv4 解析 /proc/net/route 中以小端序十六进制表示的IPv4地址
+func parseRouteIPv4(s string) string {
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || value == 0 {
+		return ""
+	}
+	ip := make(net.IP, 4)
+	binary.LittleEndian.PutUint32(ip, uint32(value))
+	return ip.String()
+}
+
+// parseRouteIPv6 解析 /proc/net/ipv6_route 中的十六进制IPv6地址
+func parseRouteIPv6(s string) string {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != net.IPv6len {
+		return ""
+	}
+	ip := net.IP(data)
+	if ip.IsUnspecified() {
+		return ""
+	}
+	return ip.String()
+}
@@ -0,0 +1,72 @@
+package network
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDefaultRoute(t *testing.T) {
+	cases := []struct {
+		name  string
+		file  string
+		parse func(io.Reader) (defaultRoute, bool)
+		want  defaultRoute
+	}{
+		// docker0 未启用，tun0 为不可达路由，eth0 的metric小于wlan0
+		{"IPv4", "route", parseDefaultRouteIPv4, defaultRoute{Iface: "eth0", Gateway: "10.0.0.1", Metric: 100}},
+		// eth1 未启用，lo 上为内核添加的不可达路由，eth0 的metric小于wlan0
+		{"IPv6", "ipv6_route", parseDefaultRouteIPv6, defaultRoute{Iface: "eth0", Gateway: "fe80::2", Metric: 100}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			route, ok := readDefaultRoute(filepath.Join("testdata", tc.file), tc.parse)
+			if !ok {
+				t.Fatal("没有找到默认路由")
+			}
+			if route != tc.want {
+				t.Errorf("route = %+v, 期望 %+v", route, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDefaultRouteNone(t *testing.T) {
+	// 只有表头和非默认路由
+	ipv4 := "Iface\tDestination\tGateway\tFlags\tRefCnt\tUse\tMetric\tMask\tMTU\tWindow\tIRTT\n" +
+		"eth0\t0000000A\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n"
+	if route, ok := parseDefaultRouteIPv4(strings.NewReader(ipv4)); ok {
+		t.Errorf("IPv4 route = %+v, 期望没有默认路由", route)
+	}
+	if route, ok := parseDefaultRouteIPv6(strings.NewReader("")); ok {
+		t.Errorf("IPv6 route = %+v, 期望没有默认路由", route)
+	}
+	if _, ok := readDefaultRoute(filepath.Join("testdata", "missing"), parseDefaultRouteIPv4); ok {
+		t.Error("文件不存在时期望返回false")
+	}
+}
+
+func TestParseRouteAddr(t *testing.T) {
+	ipv4 := map[string]string{
+		"0101A8C0": "192.168.1.1",
+		"00000000": "",
+		"zz":       "",
+	}
+	for in, want := range ipv4 {
+		if got := parseRouteIPv4(in); got != want {
+			t.Errorf("parseRouteIPv4(%q) = %q, 期望 %q", in, got, want)
+		}
+	}
+
+	ipv6 := map[string]string{
+		"20010db8000000000000000000000001": "2001:db8::1",
+		"00000000000000000000000000000000": "",
+		"2001":                             "",
+	}
+	for in, want := range ipv6 {
+		if got := parseRouteIPv6(in); got != want {
+			t.Errorf("parseRouteIPv6(%q) = %q, 期望 %q", in, got, want)
+		}
+	}
+}
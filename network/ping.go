@@ -0,0 +1,324 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Ping的方式
+const (
+	PingMethodICMPUDP = "icmp-udp" // 非特权ICMP数据报套接字，Linux需要 net.ipv4.ping_group_range 包含当前用户组
+	PingMethodICMPRaw = "icmp-raw" // 原始套接字，需要root或CAP_NET_RAW
+)
+
+// PingOptions Ping的参数
+type PingOptions struct {
+	Count    int           // 发送的包数
+	Interval time.Duration // 发包间隔
+	Size     int           // ICMP负载的字节数
+	Timeout  time.Duration // 最后一个包发出后等待回复的时间，TCP探测时为单次连接的超时
+	Port     int           // TCP探测的端口，为0时根据URL协议选择443或80
+}
+
+// DefaultPingOptions PingHost 和站点测试使用的参数，可通过 SetPingOptions 修改
+var DefaultPingOptions = PingOptions{
+	Count:    5,
+	Interval: 200 * time.Millisecond,
+	Size:     56,
+	Timeout:  time.Second,
+}
+
+// SetPingOptions 设置 PingHost 和站点测试使用的参数，为0的项保持默认值
+func SetPingOptions(opts PingOptions) {
+	DefaultPingOptions = opts.withDefaults()
+}
+
+// withDefaults 将为0的项替换为默认值
+func (o PingOptions) withDefaults() PingOptions {
+	if o.Count <= 0 {
+		o.Count = DefaultPingOptions.Count
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultPingOptions.Interval
+	}
+	if o.Size <= 0 {
+		o.Size = DefaultPingOptions.Size
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultPingOptions.Timeout
+	}
+	return o
+}
+
+// PingPacket 单个包的结果
+type PingPacket struct {
+	Seq      int           `json:"seq"`
+	Received bool          `json:"received"`
+	RTT      time.Duration `json:"rtt"` // 未收到回复时为0
+}
+
+// PingStats Ping的统计结果
+type PingStats struct {
+	Host     string        `json:"host"`
+	Addr     string        `json:"addr"`   // 实际Ping的IP地址
+	Method   string        `json:"method"` // icmp-udp 或 icmp-raw
+	Sent     int           `json:"sent"`
+	Received int           `json:"received"`
+	Loss     float64       `json:"loss"` // 丢包率 (0-1)
+	Min      time.Duration `json:"min"`
+	Avg      time.Duration `json:"avg"`
+	Max      time.Duration `json:"max"`
+	StdDev   time.Duration `json:"stddev"`
+	Jitter   time.Duration `json:"jitter"` // 相邻两次RTT之差的平均值
+	Packets  []PingPacket  `json:"packets"`
+}
+
+// pingCalls Ping的调用次数，用于为每次调用生成不同的Echo ID
+var pingCalls uint32
+
+// peerIP 返回回复来源的IP地址
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+// pingConn 打开的ICMP连接
+type pingConn struct {
+	conn      *icmp.PacketConn
+	method    string
+	dst       net.Addr
+	proto     int       // 解析回复使用的协议号，IPv4为1，IPv6为58
+	echoType  icmp.Type // 请求的类型
+	replyType icmp.Type // 回复的类型
+}
+
+// listenPing 为目标地址打开ICMP连接，优先使用非特权的数据报套接字，失败时使用原始套接字
+func listenPing(ip net.IP) (*pingConn, error) {
+	v4 := ip.To4() != nil
+	pc := &pingConn{proto: 1, echoType: ipv4.ICMPTypeEcho, replyType: ipv4.ICMPTypeEchoReply}
+	udpNetwork, rawNetwork, laddr := "udp4", "ip4:icmp", "0.0.0.0"
+	if !v4 {
+		pc.proto, pc.echoType, pc.replyType = 58, ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		udpNetwork, rawNetwork, laddr = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	conn, udpErr := icmp.ListenPacket(udpNetwork, laddr)
+	if udpErr == nil {
+		pc.conn, pc.method, pc.dst = conn, PingMethodICMPUDP, &net.UDPAddr{IP: ip}
+		return pc, nil
+	}
+	conn, rawErr := icmp.ListenPacket(rawNetwork, laddr)
+	if rawErr != nil {
+		return nil, fmt.Errorf("无法打开ICMP套接字: %v; %v", udpErr, rawErr)
+	}
+	pc.conn, pc.method, pc.dst = conn, PingMethodICMPRaw, &net.IPAddr{IP: ip}
+	return pc, nil
+}
+
+// resolvePingAddr 从主机名或URL解析出要Ping的IP，优先IPv4
+func resolvePingAddr(ctx context.Context, host string) (net.IP, error) {
+	host = hostFromURL(host)
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%s 没有可用的IP地址", host)
+	}
+	return addrs[0].IP, nil
+}
+
+// Ping 向主机发送ICMP Echo请求并统计延迟和丢包率，host 可以是IP、域名或URL
+func Ping(ctx context.Context, host string, opts PingOptions) (*PingStats, error) {
+	opts = opts.withDefaults()
+
+	ip, err := resolvePingAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := listenPing(ip)
+	if err != nil {
+		return nil, err
+	}
+	defer pc.conn.Close()
+
+	stats := &PingStats{
+		Host:    host,
+		Addr:    ip.String(),
+		Method:  pc.method,
+		Packets: make([]PingPacket, opts.Count),
+	}
+
+	// 数据报套接字由内核分配并校验ID，原始套接字会收到本机所有的ICMP包，
+	// 每次调用使用不同的ID，并发Ping时才不会把其他调用的回复计入
+	id := (os.Getpid() + int(atomic.AddUint32(&pingCalls, 1))) & 0xffff
+	payload := make([]byte, opts.Size)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	var mu sync.Mutex
+	sentAt := make([]time.Time, opts.Count)
+	done := make(chan struct{})
+
+	// 接收回复，直到所有包都收到回复或超过截止时间
+	deadline := time.Now().Add(time.Duration(opts.Count-1)*opts.Interval + opts.Timeout)
+	pc.conn.SetReadDeadline(deadline)
+	go func() {
+		defer close(done)
+		buf := make([]byte, opts.Size+128)
+		received := 0
+		for received < opts.Count {
+			n, peer, err := pc.conn.ReadFrom(buf)
+			now := time.Now()
+			if err != nil {
+				return
+			}
+			if !peerIP(peer).Equal(ip) {
+				continue
+			}
+			msg, err := icmp.ParseMessage(pc.proto, buf[:n])
+			if err != nil || msg.Type != pc.replyType {
+				continue
+			}
+			echo, ok := msg.Body.(*icmp.Echo)
+			if !ok || (pc.method == PingMethodICMPRaw && echo.ID != id) {
+				continue
+			}
+
+			mu.Lock()
+			seq := echo.Seq
+			if seq >= 0 && seq < opts.Count && !sentAt[seq].IsZero() && !stats.Packets[seq].Received {
+				stats.Packets[seq].Received = true
+				stats.Packets[seq].RTT = now.Sub(sentAt[seq])
+				received++
+			}
+			mu.Unlock()
+		}
+	}()
+
+	// ctx 取消时关闭连接以结束接收
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pc.conn.Close()
+		case <-stop:
+		}
+	}()
+
+	// 按间隔发送请求
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for seq := 0; seq < opts.Count; seq++ {
+		if seq > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		msg := icmp.Message{
+			Type: pc.echoType,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: payload},
+		}
+		data, err := msg.Marshal(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		stats.Packets[seq].Seq = seq
+		sentAt[seq] = time.Now()
+		mu.Unlock()
+		if _, err := pc.conn.WriteTo(data, pc.dst); err != nil {
+			return nil, fmt.Errorf("发送ICMP请求失败: %v", err)
+		}
+		stats.Sent++
+	}
+	<-done
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	stats.Packets = stats.Packets[:stats.Sent]
+	stats.compute()
+	if stats.Received == 0 {
+		return stats, errors.New("没有收到任何回复")
+	}
+	return stats, nil
+}
+
+// compute 根据每个包的结果计算丢包率和延迟统计
+func (s *PingStats) compute() {
+	var rtts []time.Duration
+	for _, p := range s.Packets {
+		if p.Received {
+			rtts = append(rtts, p.RTT)
+		}
+	}
+	s.Received = len(rtts)
+	if s.Sent > 0 {
+		s.Loss = float64(s.Sent-s.Received) / float64(s.Sent)
+	}
+	if len(rtts) == 0 {
+		return
+	}
+
+	var sum, jitter float64
+	s.Min, s.Max = rtts[0], rtts[0]
+	for i, rtt := range rtts {
+		sum += float64(rtt)
+		if rtt < s.Min {
+			s.Min = rtt
+		}
+		if rtt > s.Max {
+			s.Max = rtt
+		}
+		if i > 0 {
+			jitter += math.Abs(float64(rtt - rtts[i-1]))
+		}
+	}
+	avg := sum / float64(len(rtts))
+	s.Avg = time.Duration(avg)
+
+	var variance float64
+	for _, rtt := range rtts {
+		variance += (float64(rtt) - avg) * (float64(rtt) - avg)
+	}
+	s.StdDev = time.Duration(math.Sqrt(variance / float64(len(rtts))))
+	if len(rtts) > 1 {
+		s.Jitter = time.Duration(jitter / float64(len(rtts)-1))
+	}
+}
@@ -0,0 +1,99 @@
+package network
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// skipWithoutICMP 当前环境无法打开ICMP套接字时跳过测试
+func skipWithoutICMP(t *testing.T) {
+	t.Helper()
+	pc, err := listenPing(net.ParseIP("127.0.0.1"))
+	if err != nil {
+		t.Skipf("无法打开ICMP套接字: %v", err)
+	}
+	pc.conn.Close()
+}
+
+func TestPingLoopback(t *testing.T) {
+	skipWithoutICMP(t)
+
+	opts := PingOptions{Count: 3, Interval: 20 * time.Millisecond, Timeout: 500 * time.Millisecond}
+	stats, err := Ping(context.Background(), "127.0.0.1", opts)
+	if err != nil {
+		t.Fatalf("Ping 127.0.0.1 失败: %v", err)
+	}
+	if stats.Sent != 3 || stats.Received != 3 || stats.Loss != 0 {
+		t.Fatalf("sent=%d recv=%d loss=%.2f, 期望 3/3/0", stats.Sent, stats.Received, stats.Loss)
+	}
+	if len(stats.Packets) != 3 {
+		t.Fatalf("len(Packets)=%d, 期望 3", len(stats.Packets))
+	}
+	for _, p := range stats.Packets {
+		if !p.Received || p.RTT <= 0 {
+			t.Errorf("包 %d: received=%v rtt=%v", p.Seq, p.Received, p.RTT)
+		}
+	}
+	if stats.Min > stats.Avg || stats.Avg > stats.Max {
+		t.Errorf("min=%v avg=%v max=%v 不满足 min<=avg<=max", stats.Min, stats.Avg, stats.Max)
+	}
+}
+
+// TestPingConcurrent 并发Ping时不可达主机不能收到其他调用的回复
+func TestPingConcurrent(t *testing.T) {
+	skipWithoutICMP(t)
+
+	opts := PingOptions{Count: 5, Interval: 20 * time.Millisecond, Timeout: 300 * time.Millisecond}
+	hosts := []string{"127.0.0.1", "10.255.254.254"}
+	stats := make([]*PingStats, len(hosts))
+	errs := make([]error, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			stats[i], errs[i] = Ping(context.Background(), host, opts)
+		}(i, host)
+	}
+	wg.Wait()
+
+	if errs[0] != nil || stats[0].Received != opts.Count {
+		t.Fatalf("127.0.0.1: stats=%+v err=%v, 期望收到全部回复", stats[0], errs[0])
+	}
+	// 无路由时发送会直接失败，stats 为nil
+	if stats[1] != nil && stats[1].Received != 0 {
+		t.Fatalf("10.255.254.254: recv=%d loss=%.2f, 期望没有回复", stats[1].Received, stats[1].Loss)
+	}
+	if errs[1] == nil {
+		t.Fatal("10.255.254.254: 期望返回错误")
+	}
+}
+
+func TestPingStatsCompute(t *testing.T) {
+	ms := time.Millisecond
+	s := &PingStats{
+		Sent: 4,
+		Packets: []PingPacket{
+			{Seq: 0, Received: true, RTT: 10 * ms},
+			{Seq: 1, Received: true, RTT: 30 * ms},
+			{Seq: 2},
+			{Seq: 3, Received: true, RTT: 20 * ms},
+		},
+	}
+	s.compute()
+
+	if s.Received != 3 || s.Loss != 0.25 {
+		t.Fatalf("recv=%d loss=%.2f, 期望 3/0.25", s.Received, s.Loss)
+	}
+	if s.Min != 10*ms || s.Max != 30*ms || s.Avg != 20*ms {
+		t.Errorf("min=%v avg=%v max=%v, 期望 10ms/20ms/30ms", s.Min, s.Avg, s.Max)
+	}
+	// 相邻差值为20ms和10ms
+	if s.Jitter != 15*ms {
+		t.Errorf("jitter=%v, 期望 15ms", s.Jitter)
+	}
+}
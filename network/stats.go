@@ -0,0 +1,75 @@
+package network
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyStats 多次采样的延迟分布
+type LatencyStats struct {
+	Samples int           // 参与统计的样本数
+	Min     time.Duration // 最小值
+	Median  time.Duration // 中位数
+	P90     time.Duration // 第90百分位
+	P99     time.Duration // 第99百分位
+	Max     time.Duration // 最大值
+	StdDev  time.Duration // 标准差
+}
+
+// NewLatencyStats 计算样本的延迟分布，没有样本时返回零值
+func NewLatencyStats(samples []time.Duration) LatencyStats {
+	stats := LatencyStats{Samples: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Median = median(sorted)
+	stats.P90 = percentile(sorted, 90)
+	stats.P99 = percentile(sorted, 99)
+
+	var sum float64
+	for _, d := range sorted {
+		sum += float64(d)
+	}
+	avg := sum / float64(len(sorted))
+	var variance float64
+	for _, d := range sorted {
+		variance += (float64(d) - avg) * (float64(d) - avg)
+	}
+	stats.StdDev = time.Duration(math.Sqrt(variance / float64(len(sorted))))
+	return stats
+}
+
+// percentile 使用最近秩法计算已排序样本的第p百分位
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// median 计算已排序样本的中位数，样本数为偶数时取中间两个的平均值
+func median(sorted []time.Duration) time.Duration {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// medianOf 计算未排序样本的中位数
+func medianOf(samples []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return median(sorted)
+}
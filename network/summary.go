@@ -0,0 +1,96 @@
+package network
+
+import "time"
+
+// Summary 站点测试结果的汇总统计
+type Summary struct {
+	TotalSites      int           // 测试站点总数
+	AccessibleSites int           // 可访问站点数
+	AccessRate      float64       // 可访问率 (0-100)
+	AvgResponseTime time.Duration // 可访问站点的平均响应时间
+	AvgPingTime     time.Duration // 有Ping结果站点的平均延迟
+	AvgGenerate204  time.Duration // 有Generate_204结果站点的平均延迟
+
+	MedianResponseTime time.Duration // 可访问站点响应时间的中位数
+	MedianPingTime     time.Duration // 有Ping结果站点延迟的中位数
+	MedianGenerate204  time.Duration // 有Generate_204结果站点延迟的中位数
+}
+
+// Summarize 计算站点测试结果的汇总统计
+func Summarize(results []SiteTestResult) Summary {
+	summary := Summary{TotalSites: len(results)}
+
+	var totalRespTime, totalPingTime, totalGenerate204 time.Duration
+	var respTimes, pingTimes, generate204Times []time.Duration
+	respTimeCount, pingTimeCount, generate204Count := 0, 0, 0
+
+	for _, result := range results {
+		if result.Accessible {
+			summary.AccessibleSites++
+			totalRespTime += result.ResponseTime
+			respTimes = append(respTimes, result.ResponseTime)
+			respTimeCount++
+		}
+
+		if result.PingTime > 0 {
+			totalPingTime += result.PingTime
+			pingTimes = append(pingTimes, result.PingTime)
+			pingTimeCount++
+		}
+
+		if result.Generate204 > 0 {
+			totalGenerate204 += result.Generate204
+			generate204Times = append(generate204Times, result.Generate204)
+			generate204Count++
+		}
+	}
+
+	// 计算平均值
+	if respTimeCount > 0 {
+		summary.AvgResponseTime = totalRespTime / time.Duration(respTimeCount)
+	}
+	if pingTimeCount > 0 {
+		summary.AvgPingTime = totalPingTime / time.Duration(pingTimeCount)
+	}
+	if generate204Count > 0 {
+		summary.AvgGenerate204 = totalGenerate204 / time.Duration(generate204Count)
+	}
+
+	// 计算中位数，少数很慢的站点不会拉高整体结果
+	summary.MedianResponseTime = medianOf(respTimes)
+	summary.MedianPingTime = medianOf(pingTimes)
+	summary.MedianGenerate204 = medianOf(generate204Times)
+
+	// 计算可访问率
+	if summary.TotalSites > 0 {
+		summary.AccessRate = float64(summary.AccessibleSites) / float64(summary.TotalSites) * 100
+	}
+
+	return summary
+}
+
+// RatingThreshold 网络评级的阈值
+// 可访问率不低于 MinAccessRate，且响应时间和Ping延迟的中位数都低于上限时达到该评级
+type RatingThreshold struct {
+	Rating          string        // 评级名称
+	MinAccessRate   float64       // 最低可访问率 (0-100)
+	MaxResponseTime time.Duration // 响应时间中位数上限
+	MaxPingTime     time.Duration // Ping延迟中位数上限
+}
+
+// RatingThresholds 从高到低排列的评级阈值，都不满足时评级为"较差"
+var RatingThresholds = []RatingThreshold{
+	{"优秀", 90, 1 * time.Second, 100 * time.Millisecond},
+	{"良好", 70, 2 * time.Second, 200 * time.Millisecond},
+	{"一般", 50, 3 * time.Second, 300 * time.Millisecond},
+}
+
+// Rating 根据可访问率和中位数延迟评估网络状况，与汇总卡片显示的数值一致，个别很慢的站点不会拉低评级
+func (s Summary) Rating() string {
+	for _, t := range RatingThresholds {
+		if s.AccessRate >= t.MinAccessRate && s.MedianResponseTime < t.MaxResponseTime && s.MedianPingTime < t.MaxPingTime {
+			return t.Rating
+		}
+	}
+	return "较差"
+}
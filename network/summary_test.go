@@ -0,0 +1,23 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSummaryRatingUsesMedian 个别很慢的站点不会拉低评级
+func TestSummaryRatingUsesMedian(t *testing.T) {
+	ms := time.Millisecond
+	results := []SiteTestResult{
+		{Accessible: true, ResponseTime: 200 * ms, PingTime: 20 * ms},
+		{Accessible: true, ResponseTime: 300 * ms, PingTime: 30 * ms},
+		{Accessible: true, ResponseTime: 9 * time.Second, PingTime: 900 * ms},
+	}
+	s := Summarize(results)
+	if s.MedianResponseTime != 300*ms || s.MedianPingTime != 30*ms {
+		t.Fatalf("median resp=%v ping=%v, 期望 300ms/30ms", s.MedianResponseTime, s.MedianPingTime)
+	}
+	if got := s.Rating(); got != "优秀" {
+		t.Errorf("Rating() = %q, 期望 优秀 (avg resp=%v ping=%v)", got, s.AvgResponseTime, s.AvgPingTime)
+	}
+}
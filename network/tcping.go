@@ -0,0 +1,90 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PingMethodTCP 通过TCP三次握手测量延迟，用于ICMP被屏蔽的站点
+const PingMethodTCP = "tcp"
+
+// portFromURL 返回URL中显式指定的端口，未指定时https为443，其他为80
+func portFromURL(url string) int {
+	host := strings.TrimPrefix(url, "http://")
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.Split(host, "/")[0]
+	if _, p, err := net.SplitHostPort(host); err == nil {
+		if port, err := strconv.Atoi(p); err == nil {
+			return port
+		}
+	}
+	if strings.HasPrefix(url, "https://") {
+		return 443
+	}
+	return 80
+}
+
+// TCPing 按 opts 的包数和间隔与主机的端口建立TCP连接，以握手耗时作为延迟，
+// 连接失败或超时计为丢包。port 为0时使用 opts.Port，仍为0时根据URL协议选择443或80
+func TCPing(ctx context.Context, host string, port int, opts PingOptions) (*PingStats, error) {
+	opts = opts.withDefaults()
+	if port <= 0 {
+		port = opts.Port
+	}
+	if port <= 0 {
+		port = portFromURL(host)
+	}
+
+	ip, err := resolvePingAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+
+	stats := &PingStats{
+		Host:    host,
+		Addr:    addr,
+		Method:  PingMethodTCP,
+		Packets: make([]PingPacket, 0, opts.Count),
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	var lastErr error
+	for seq := 0; seq < opts.Count; seq++ {
+		if seq > 0 {
+			select {
+			case <-time.After(opts.Interval):
+			case <-ctx.Done():
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		packet := PingPacket{Seq: seq}
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			packet.Received = true
+			packet.RTT = time.Since(start)
+			conn.Close()
+		} else {
+			lastErr = err
+		}
+		stats.Packets = append(stats.Packets, packet)
+		stats.Sent++
+	}
+
+	stats.compute()
+	if stats.Received == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("没有建立任何连接")
+		}
+		return stats, lastErr
+	}
+	return stats, nil
+}
@@ -4,9 +4,6 @@ import (
 	"context"
 	"net"
 	"net/http"
-	"os/exec"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -19,26 +16,55 @@ func SetGlobalTimeout(timeout time.Duration) {
 	globalTimeout = timeout
 }
 
+// 每个站点HTTP测试的采样次数和间隔
+var (
+	sampleCount    = 1
+	sampleInterval = time.Second
+)
+
+// SetSampleOptions 设置每个站点HTTP测试的采样次数和采样间隔，为0的项保持不变
+func SetSampleOptions(count int, interval time.Duration) {
+	if count > 0 {
+		sampleCount = count
+	}
+	if interval > 0 {
+		sampleInterval = interval
+	}
+}
+
 // SiteTestResult 存储站点测试结果
 type SiteTestResult struct {
-	Name         string        // 站点名称
-	URL          string        // 站点URL
-	Accessible   bool          // 是否可访问
-	ResponseTime time.Duration // 响应时间
-	StatusCode   int           // HTTP状态码
-	Error        string        // 错误信息
-	DNSTime      time.Duration // DNS解析时间
-	ConnectTime  time.Duration // 连接建立时间
-	PingTime     time.Duration // Ping延迟时间
-	PingLoss     float64       // Ping丢包率 (0-1)
-	Generate204  time.Duration // Generate_204延迟测试
+	Name          string        // 站点名称
+	URL           string        // 站点URL
+	Accessible    bool          // 是否可访问
+	ResponseTime  time.Duration // 总响应时间(含重定向和读取响应体)，多次采样时为中位数
+	StatusCode    int           // HTTP状态码
+	Error         string        // 错误信息
+	DNSTime       time.Duration // DNS解析时间，各跳之和，多次采样时为中位数
+	ConnectTime   time.Duration // 连接建立时间，各跳之和，多次采样时为中位数
+	TLSTime       time.Duration // TLS握手时间，各跳之和
+	ServerTime    time.Duration // 服务器处理时间(请求发出到首字节)，各跳之和
+	TransferTime  time.Duration // 内容传输时间，各跳之和
+	Hops          []HTTPTiming  // 每一跳(含重定向)的耗时明细
+	Samples       int           // HTTP测试的采样次数
+	Succeeded     int           // 成功的采样次数
+	ResponseStats LatencyStats  // 成功采样的响应时间分布
+	DNSStats      LatencyStats  // 成功采样的DNS解析时间分布
+	ConnectStats  LatencyStats  // 成功采样的连接建立时间分布
+	PingTime      time.Duration // Ping延迟时间
+	PingLoss      float64       // Ping丢包率 (0-1)
+	PingMethod    string        // 产生Ping结果的方式: icmp-udp、icmp-raw 或 tcp
+	Generate204   time.Duration // Generate_204延迟测试
+}
+
+// Site 要测试的站点
+type Site struct {
+	Name string // 站点名称
+	URL  string // 站点URL
 }
 
 // CommonSites 定义要测试的常用站点列表
-var CommonSites = []struct {
-	Name string
-	URL  string
-}{
+var CommonSites = []Site{
 	{"Google", "https://www.google.com"},
 	{"GitHub", "https://github.com"},
 	{"YouTube", "https://www.youtube.com"},
@@ -53,6 +79,11 @@ var CommonSites = []struct {
 
 // TestGenerate204 测试generate_204延迟
 func TestGenerate204(host string) (time.Duration, error) {
+	return testGenerate204(context.Background(), host)
+}
+
+// testGenerate204 测试generate_204延迟，ctx 取消时中止请求
+func testGenerate204(ctx context.Context, host string) (time.Duration, error) {
 	// 从URL中提取主机名
 	host = strings.TrimPrefix(host, "http://")
 	host = strings.TrimPrefix(host, "https://")
@@ -83,8 +114,12 @@ func TestGenerate204(host string) (time.Duration, error) {
 	}
 
 	// 发送请求并测量响应时间
+	req, err := http.NewRequestWithContext(ctx, "GET", generate204URL, nil)
+	if err != nil {
+		return 0, err
+	}
 	startTime := time.Now()
-	resp, err := client.Get(generate204URL)
+	resp, err := client.Do(req)
 	if err != nil {
 		return 0, err
 	}
@@ -97,123 +132,155 @@ func TestGenerate204(host string) (time.Duration, error) {
 
 // PingHost 测试主机的延迟和丢包率
 func PingHost(host string) (time.Duration, float64, error) {
-	// 从URL中提取主机名
-	host = strings.TrimPrefix(host, "http://")
-	host = strings.TrimPrefix(host, "https://")
-	host = strings.Split(host, "/")[0]
-
-	// 执行ping命令
-	cmd := exec.Command("ping", "-c", "5", "-i", "0.2", host)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	stats, err := pingHost(context.Background(), host)
+	if stats == nil {
 		return 0, 1.0, err // 返回最大丢包率1.0表示100%丢包
 	}
+	return stats.Avg, stats.Loss, err
+}
 
-	// 解析ping输出
-	outputStr := string(output)
-
-	// 提取平均延迟
-	avgTimeRegex := regexp.MustCompile(`min/avg/max/(?:stddev|mdev) = [\d.]+/([\d.]+)/[\d.]+/[\d.]+`)
-	avgTimeMatch := avgTimeRegex.FindStringSubmatch(outputStr)
-
-	var avgTime float64
-	if len(avgTimeMatch) >= 2 {
-		avgTime, _ = strconv.ParseFloat(avgTimeMatch[1], 64)
+// pingHost 使用 DefaultPingOptions 测试主机的延迟和丢包率，ctx 取消时停止发包。
+// 很多站点屏蔽了ICMP，无法打开ICMP套接字或全部丢包时改用TCP握手测量
+func pingHost(ctx context.Context, host string) (*PingStats, error) {
+	stats, err := Ping(ctx, host, DefaultPingOptions)
+	if (stats != nil && stats.Received > 0) || ctx.Err() != nil {
+		return stats, err
 	}
-
-	// 提取丢包率
-	packetLossRegex := regexp.MustCompile(`(\d+)% packet loss`)
-	packetLossMatch := packetLossRegex.FindStringSubmatch(outputStr)
-
-	var packetLoss float64
-	if len(packetLossMatch) >= 2 {
-		lossPercent, _ := strconv.ParseFloat(packetLossMatch[1], 64)
-		packetLoss = lossPercent / 100.0
+	tcpStats, tcpErr := TCPing(ctx, host, 0, DefaultPingOptions)
+	if tcpStats == nil {
+		// 域名解析失败等情况两种方式都无法测量，保留ICMP的结果
+		if stats != nil {
+			return stats, err
+		}
+		return nil, tcpErr
 	}
+	return tcpStats, tcpErr
+}
 
-	return time.Duration(avgTime * float64(time.Millisecond)), packetLoss, nil
+// hostFromURL 从URL中提取主机名，去掉协议、路径和端口
+func hostFromURL(url string) string {
+	host := strings.TrimPrefix(url, "http://")
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.Split(host, "/")[0]
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.Trim(host, "[]")
 }
 
 // TestSite 测试单个站点的可访问性、响应时间和延迟
-func TestSite(site struct {
-	Name string
-	URL  string
-}) SiteTestResult {
+func TestSite(site Site) SiteTestResult {
+	return TestSiteContext(context.Background(), site)
+}
+
+// TestSiteContext 测试单个站点的可访问性、响应时间和延迟，ctx 取消时中止所有测试。
+// HTTP测试按 SetSampleOptions 设置的次数重复，响应时间、DNS和连接时间取成功采样的中位数
+func TestSiteContext(ctx context.Context, site Site) SiteTestResult {
 	result := SiteTestResult{
 		Name: site.Name,
 		URL:  site.URL,
 	}
 
-	// 创建自定义的Transport，以便测量DNS和连接时间
-	var dnsStart, dnsEnd, connectStart, connectEnd time.Time
+	var samples []SiteTestResult
+	var responseTimes, dnsTimes, connectTimes []time.Duration
+	for i := 0; i < sampleCount; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(sampleInterval):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sample := result
+		probeHTTP(ctx, &sample)
+		samples = append(samples, sample)
+		if sample.Accessible {
+			responseTimes = append(responseTimes, sample.ResponseTime)
+			dnsTimes = append(dnsTimes, sample.DNSTime)
+			connectTimes = append(connectTimes, sample.ConnectTime)
+		}
+	}
+
+	// 状态码、错误和每跳明细使用最后一次成功的采样，全部失败时使用最后一次采样
+	for _, sample := range samples {
+		if sample.Accessible || len(responseTimes) == 0 {
+			result = sample
+		}
+	}
+	result.Samples = len(samples)
+	result.Succeeded = len(responseTimes)
+	result.ResponseStats = NewLatencyStats(responseTimes)
+	result.DNSStats = NewLatencyStats(dnsTimes)
+	result.ConnectStats = NewLatencyStats(connectTimes)
+	if len(responseTimes) > 1 {
+		result.ResponseTime = result.ResponseStats.Median
+		result.DNSTime = result.DNSStats.Median
+		result.ConnectTime = result.ConnectStats.Median
+	}
 
-	dialer := &net.Dialer{
-		Timeout:   globalTimeout,
-		KeepAlive: globalTimeout / 2,
+	// 执行Ping测试
+	result.PingLoss = 1.0
+	if stats, _ := pingHost(ctx, site.URL); stats != nil {
+		result.PingTime = stats.Avg
+		result.PingLoss = stats.Loss
+		result.PingMethod = stats.Method
 	}
 
-	transport := &http.Transport{
-		Proxy:                 http.ProxyFromEnvironment,
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			dnsStart = time.Now()
-			host, port, err := net.SplitHostPort(addr)
-			if err != nil {
-				return nil, err
-			}
+	// 执行Generate_204测试
+	generate204Time, _ := testGenerate204(ctx, site.URL)
+	result.Generate204 = generate204Time
 
-			// 解析IP地址
-			ips, err := net.LookupIP(host)
-			if err != nil {
-				return nil, err
-			}
-			dnsEnd = time.Now()
+	return result
+}
 
-			// 连接到服务器
-			connectStart = time.Now()
-			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
-			connectEnd = time.Now()
-			return conn, err
-		},
+// probeHTTP 请求一次站点并将状态和各阶段耗时写入 result
+func probeHTTP(ctx context.Context, result *SiteTestResult) {
+	// 每次测试使用独立的Transport，避免复用其他站点的连接影响计时
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   globalTimeout,
+			KeepAlive: globalTimeout / 2,
+		}).DialContext,
 		MaxIdleConns:          100,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   globalTimeout / 2,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
+	defer transport.CloseIdleConnections()
 
+	// 重定向由 traceRedirects 逐跳处理，以便记录每一跳的耗时
 	client := &http.Client{
 		Transport: transport,
 		Timeout:   globalTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
 	}
 
-	// 发送请求并测量响应时间
-	startTime := time.Now()
-	resp, err := client.Get(site.URL)
-
-	// 记录DNS和连接时间
-	result.DNSTime = dnsEnd.Sub(dnsStart)
-	result.ConnectTime = connectEnd.Sub(connectStart)
+	// 发送请求并测量各阶段耗时
+	hops, resp, err := traceRedirects(ctx, client, result.URL)
+	result.Hops = hops
+	for _, hop := range hops {
+		result.DNSTime += hop.DNS
+		result.ConnectTime += hop.Connect
+		result.TLSTime += hop.TLSHandshake
+		result.ServerTime += hop.ServerProcessing
+		result.TransferTime += hop.ContentTransfer
+		result.ResponseTime += hop.Total
+	}
 
 	if err != nil {
 		result.Accessible = false
 		result.Error = err.Error()
-	} else {
-		defer resp.Body.Close()
-		// 计算总响应时间
-		result.ResponseTime = time.Since(startTime)
+	}
+	if resp != nil {
 		result.StatusCode = resp.StatusCode
-		result.Accessible = resp.StatusCode >= 200 && resp.StatusCode < 400
+		result.Accessible = err == nil && resp.StatusCode >= 200 && resp.StatusCode < 400
 	}
-
-	// 执行Ping测试
-	pingTime, pingLoss, _ := PingHost(site.URL)
-	result.PingTime = pingTime
-	result.PingLoss = pingLoss
-
-	// 执行Generate_204测试
-	generate204Time, _ := TestGenerate204(site.URL)
-	result.Generate204 = generate204Time
-
-	return result
 }
 
 // TestCommonSites 测试所有常用站点
@@ -225,10 +292,7 @@ func TestCommonSites() []SiteTestResult {
 
 	// 并发测试所有站点
 	for _, site := range CommonSites {
-		go func(s struct {
-			Name string
-			URL  string
-		}) {
+		go func(s Site) {
 			resultChan <- TestSite(s)
 		}(site)
 	}
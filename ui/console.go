@@ -2,10 +2,12 @@ package ui
 
 import (
 	"fmt"
-	"ip/network"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/network"
 )
 
 // 定义ANSI颜色代码
@@ -85,48 +87,53 @@ const (
 	IconLoading     = "⏳"
 )
 
-// IPInfo 结构体，与cmd/ip.go中的IPInfo保持一致
-type IPInfo struct {
-	IP             string  `json:"ip"`
-	Network        string  `json:"network"`
-	Version        string  `json:"version"`
-	City           string  `json:"city"`
-	Region         string  `json:"region"`
-	RegionCode     string  `json:"region_code"`
-	Country        string  `json:"country"`
-	CountryName    string  `json:"country_name"`
-	CountryCode    string  `json:"country_code"`
-	CountryCodeISO string  `json:"country_code_iso3"`
-	CountryCapital string  `json:"country_capital"`
-	CountryTLD     string  `json:"country_tld"`
-	ContinentCode  string  `json:"continent_code"`
-	InEU           bool    `json:"in_eu"`
-	Postal         string  `json:"postal"`
-	Latitude       float64 `json:"latitude"`
-	Longitude      float64 `json:"longitude"`
-	Timezone       string  `json:"timezone"`
-	UTCOffset      string  `json:"utc_offset"`
-	CallingCode    string  `json:"country_calling_code"`
-	Currency       string  `json:"currency"`
-	CurrencyName   string  `json:"currency_name"`
-	Languages      string  `json:"languages"`
-	CountryArea    float64 `json:"country_area"`
-	Population     int64   `json:"country_population"`
-	ASN            string  `json:"asn"`
-	Org            string  `json:"org"`
-	// 额外字段，不是API直接返回的
-	IsPure         bool    `json:"-"`
-	PureScore      int     `json:"-"`
-	PureType       string  `json:"-"`
-	IPType         string  `json:"-"` // 家宽/独立IP/共享IP
-	IsProxy        bool    `json:"-"` // 是否是代理IP
-	IsDC           bool    `json:"-"` // 是否是数据中心IP
-	APISource      string  `json:"-"` // 记录数据来源的API
+// ProviderStatus IP信息提供者的健康状态，用于 ip providers status
+type ProviderStatus struct {
+	Name         string
+	State        string  // closed/open/half-open
+	Successes    int
+	Failures     int
+	SuccessRate  float64 // 0-1
+	AvgLatencyMs float64
+	LastError    string
+	OpenUntil    time.Time
+}
+
+// Addresses 本地和公网的IPv4/IPv6地址
+type Addresses struct {
+	LocalIPv4  string
+	LocalIPv6  string
+	PublicIPv4 string
+	PublicIPv6 string
+}
+
+// LocalIP 返回首选的本地IP（优先IPv4）
+func (a Addresses) LocalIP() string {
+	if a.LocalIPv4 != "" {
+		return a.LocalIPv4
+	}
+	return a.LocalIPv6
+}
+
+// PublicIP 返回首选的公网IP（优先IPv4）
+func (a Addresses) PublicIP() string {
+	if a.PublicIPv4 != "" {
+		return a.PublicIPv4
+	}
+	return a.PublicIPv6
+}
+
+// LookupEntry 单个查询目标的结果
+type LookupEntry struct {
+	Target string        // 用户输入的IP或域名
+	IP     string        // 实际查询的IP
+	Info   *model.IPInfo // 查询到的IP信息，失败时为nil
+	Error  string        // 错误信息
 }
 
 // DrawIPInfo 绘制IP信息
-func DrawIPInfo(localIP string, publicIP string, ipInfo *IPInfo) string {
-	return RenderIPInfoWithLipgloss(localIP, publicIP, ipInfo)
+func DrawIPInfo(addrs Addresses, ipInfo *model.IPInfo) string {
+	return RenderIPInfoWithLipgloss(addrs, ipInfo)
 }
 
 // DrawBox 绘制一个带标题的框
@@ -367,7 +374,7 @@ func DrawTable(headers []string, rows [][]string, color string) string {
 }
 
 // PrintIPInfo 美观地打印IP信息
-func PrintIPInfo(localIP string, publicIP string, ipInfo *IPInfo) {
+func PrintIPInfo(localIP string, publicIP string, ipInfo *model.IPInfo) {
 	// 绘制标题
 	titleBar := BgBrightBlue + BrightWhite + Bold + "  " + IconGlobe + " IP信息查询结果 " + IconGlobe + "  " + Reset
 	fmt.Println("\n" + titleBar)
@@ -397,7 +404,7 @@ func PrintIPInfo(localIP string, publicIP string, ipInfo *IPInfo) {
 		countryInfo += " (" + BrightWhite + ipInfo.CountryCode + Reset + ")"
 	}
 
-	geoInfo := fmt.Sprintf("%s%s 国家/地区:%s %s\n%s%s 省/州:%s %s\n%s%s 城市:%s %s\n%s%s 经纬度:%s %.4f, %.4f\n%s%s 时区:%s %s",
+	geoInfo := fmt.Sprintf("%s%s 国家/地区:%s %s\n%s%s 省/州:%s %s\n%s%s 城市:%s %s\n%s%s 经纬度:%s %s\n%s%s 时区:%s %s",
 		Bold, IconFlag, Reset, countryInfo,
 		Bold, IconLocation, Reset, BrightYellow + ipInfo.Region + Reset,
 		Bold, IconBuilding, Reset, BrightYellow + ipInfo.City + Reset,
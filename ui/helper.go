@@ -24,16 +24,3 @@ func stripANSI(str string) string {
 	
 	return result
 }
-
-// getNetworkRating 根据各项指标评估网络状况
-func getNetworkRating(accessible float64, avgRespTime float64, avgPingTime float64) string {
-	if accessible >= 90 && avgRespTime < 1.0 && avgPingTime < 100 {
-		return "优秀"
-	} else if accessible >= 70 && avgRespTime < 2.0 && avgPingTime < 200 {
-		return "良好"
-	} else if accessible >= 50 && avgRespTime < 3.0 && avgPingTime < 300 {
-		return "一般"
-	} else {
-		return "较差"
-	}
-}
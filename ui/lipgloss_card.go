@@ -2,12 +2,13 @@ package ui
 
 import (
 	"fmt"
-	"ip/network"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/cikichen/MyIp/model"
+	"github.com/cikichen/MyIp/network"
 )
 
 // 使用 lipgloss 定义基本样式
@@ -75,16 +76,22 @@ func DrawLipglossCard(title string, icon string, content string, color lipgloss.
 }
 
 // RenderIPInfoWithLipgloss 使用 lipgloss 渲染 IP 信息
-func RenderIPInfoWithLipgloss(localIP string, publicIP string, ipInfo *IPInfo) string {
+func RenderIPInfoWithLipgloss(addrs Addresses, ipInfo *model.IPInfo) string {
 	// 基本IP信息卡片
 	basicInfo := lipgloss.JoinVertical(
 		lipgloss.Left,
-		fmt.Sprintf("%s 本地IP:  %s", 
-			labelStyle.Render(IconComputer), 
-			valueStyle.Render(localIP)),
-		fmt.Sprintf("%s 公网IP:  %s", 
-			labelStyle.Render(IconNetwork), 
-			accentValueStyle.Render(publicIP)),
+		fmt.Sprintf("%s 本地IPv4:  %s",
+			labelStyle.Render(IconComputer),
+			renderAddress(addrs.LocalIPv4, valueStyle)),
+		fmt.Sprintf("%s 本地IPv6:  %s",
+			labelStyle.Render(IconComputer),
+			renderAddress(addrs.LocalIPv6, valueStyle)),
+		fmt.Sprintf("%s 公网IPv4:  %s",
+			labelStyle.Render(IconNetwork),
+			renderAddress(addrs.PublicIPv4, accentValueStyle)),
+		fmt.Sprintf("%s 公网IPv6:  %s",
+			labelStyle.Render(IconNetwork),
+			renderAddress(addrs.PublicIPv6, accentValueStyle)),
 	)
 	basicCard := DrawLipglossCard("基本信息", IconInfo, basicInfo, lipgloss.Color("#5F87FF"))
 
@@ -95,6 +102,30 @@ func RenderIPInfoWithLipgloss(localIP string, publicIP string, ipInfo *IPInfo) s
 		return lipgloss.JoinVertical(lipgloss.Left, basicCard, errorCard)
 	}
 
+	// 连接所有卡片
+	result := lipgloss.JoinVertical(
+		lipgloss.Left,
+		basicCard,
+		"",
+		renderIPDetailCards(ipInfo),
+	)
+
+	// 添加完成通知
+	noticeStyle := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#87FF87")).
+		Padding(0, 1).
+		Width(78)
+		
+	notice := noticeStyle.Render(
+		labelStyle.Render(IconCheck) + " IP信息获取完成！使用 `ip nettest` 命令测试网络连通性",
+	)
+	
+	return lipgloss.JoinVertical(lipgloss.Left, result, "", notice)
+}
+
+// renderIPDetailCards 渲染IP详细信息卡片（地理位置、网络、IP类型和其他信息）
+func renderIPDetailCards(ipInfo *model.IPInfo) string {
 	// 地理位置信息卡片
 	countryInfo := accentValueStyle.Render(ipInfo.CountryName)
 	if ipInfo.CountryCode != "" {
@@ -118,7 +149,7 @@ func RenderIPInfoWithLipgloss(localIP string, publicIP string, ipInfo *IPInfo) s
 	// 处理时区显示
 	timezone := ipInfo.Timezone
 	if timezone == "" || strings.Contains(timezone, "%!") {
-		timezone = "Asia/Shanghai"
+		timezone = "未知"
 	}
 
 	geoInfo := lipgloss.JoinVertical(
@@ -134,14 +165,22 @@ func RenderIPInfoWithLipgloss(localIP string, publicIP string, ipInfo *IPInfo) s
 	// 格式化网络信息 - 处理可能存在的换行
 	orgInfo := strings.ReplaceAll(ipInfo.Org, "\n", " ")
 	
-	// 网络信息卡片
-	netInfo := lipgloss.JoinVertical(
-		lipgloss.Left,
+	// 网络信息卡片，使用API密钥时额外显示ASN详情和所属公司
+	netLines := []string{
 		fmt.Sprintf("%s ISP:    %s", labelStyle.Render(IconServer), valueStyle.Render(orgInfo)),
 		fmt.Sprintf("%s AS号:   %s", labelStyle.Render(IconNetwork), valueStyle.Render(ipInfo.ASN)),
-		fmt.Sprintf("%s API源:  %s", labelStyle.Render(IconCloud), valueStyle.Render(ipInfo.APISource)),
-	)
-	netCard := DrawLipglossCard("网络信息", IconServer, netInfo, lipgloss.Color("#87FF87"))
+	}
+	if ipInfo.ASNRoute != "" || ipInfo.ASNType != "" {
+		netLines = append(netLines,
+			fmt.Sprintf("%s AS路由:  %s %s", labelStyle.Render(IconGlobe), valueStyle.Render(ipInfo.ASNRoute), valueStyle.Render(ipInfo.ASNType)))
+	}
+	if ipInfo.CompanyName != "" {
+		netLines = append(netLines,
+			fmt.Sprintf("%s 公司:    %s %s", labelStyle.Render(IconBuilding), valueStyle.Render(ipInfo.CompanyName), valueStyle.Render(ipInfo.CompanyType)))
+	}
+	netLines = append(netLines,
+		fmt.Sprintf("%s API源:  %s", labelStyle.Render(IconCloud), valueStyle.Render(ipInfo.APISource)))
+	netCard := DrawLipglossCard("网络信息", IconServer, lipgloss.JoinVertical(lipgloss.Left, netLines...), lipgloss.Color("#87FF87"))
 
 	// IP类型信息卡片
 	var dcStatus, proxyStatus string
@@ -169,16 +208,21 @@ func RenderIPInfoWithLipgloss(localIP string, publicIP string, ipInfo *IPInfo) s
 		proxyIcon = IconCheck
 	}
 
-	typeInfo := lipgloss.JoinVertical(
-		lipgloss.Left,
+	typeLines := []string{
 		fmt.Sprintf("%s IP类型:   %s", labelStyle.Render(IconInfo), valueStyle.Render(ipInfo.IPType)),
 		fmt.Sprintf("%s 数据中心:  %s", labelStyle.Render(dcIcon), dcStatus),
 		fmt.Sprintf("%s 代理IP:   %s", labelStyle.Render(proxyIcon), proxyStatus),
+	}
+	if privacy := privacyFlags(ipInfo); privacy != "" {
+		typeLines = append(typeLines,
+			fmt.Sprintf("%s 隐私检测:  %s", labelStyle.Render(IconWarning), errorStatusStyle.Render(privacy)))
+	}
+	typeLines = append(typeLines,
 		fmt.Sprintf("%s 纯净度:   %s (%s分)", 
 			labelStyle.Render(pureIcon), 
 			accentValueStyle.Render(ipInfo.PureType), 
-			fmt.Sprintf("%d", ipInfo.PureScore)),
-	)
+			fmt.Sprintf("%d", ipInfo.PureScore)))
+	typeInfo := lipgloss.JoinVertical(lipgloss.Left, typeLines...)
 	typeCard := DrawLipglossCard("IP类型", IconNetwork, typeInfo, lipgloss.Color("#5FD7FF"))
 
 	// 其他详细信息卡片
@@ -194,80 +238,352 @@ func RenderIPInfoWithLipgloss(localIP string, publicIP string, ipInfo *IPInfo) s
 	)
 	detailCard := DrawLipglossCard("其他信息", IconInfo, detailInfo, lipgloss.Color("#D787FF"))
 
+	cards := []string{geoCard, "", netCard, "", typeCard, "", detailCard}
+
+	// 滥用联系方式卡片，仅在提供者返回时显示
+	if ipInfo.AbuseEmail != "" || ipInfo.AbuseName != "" {
+		abuseInfo := lipgloss.JoinVertical(
+			lipgloss.Left,
+			fmt.Sprintf("%s 名称:    %s", labelStyle.Render(IconBuilding), valueStyle.Render(ipInfo.AbuseName)),
+			fmt.Sprintf("%s 邮箱:    %s", labelStyle.Render(IconInfo), valueStyle.Render(ipInfo.AbuseEmail)),
+			fmt.Sprintf("%s 电话:    %s", labelStyle.Render(IconInfo), valueStyle.Render(ipInfo.AbusePhone)),
+			fmt.Sprintf("%s 网段:    %s", labelStyle.Render(IconNetwork), valueStyle.Render(ipInfo.AbuseNetwork)),
+		)
+		cards = append(cards, "", DrawLipglossCard("滥用联系", IconWarning, abuseInfo, lipgloss.Color("#FF875F")))
+	}
+
+	// 合并模式下各提供者结果不一致的字段
+	if len(ipInfo.Conflicts) > 0 {
+		cards = append(cards, "", renderConflictCard(ipInfo.Conflicts))
+	}
+
 	// 连接所有卡片
-	result := lipgloss.JoinVertical(
-		lipgloss.Left,
-		basicCard,
-		"",
-		geoCard,
-		"",
-		netCard,
-		"",
-		typeCard,
-		"",
-		detailCard,
-	)
-	
-	// 添加完成通知
-	noticeStyle := lipgloss.NewStyle().
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#87FF87")).
-		Padding(0, 1).
-		Width(78)
-		
-	notice := noticeStyle.Render(
-		labelStyle.Render(IconCheck) + " IP信息获取完成！使用 `ip nettest` 命令测试网络连通性",
-	)
-	
-	return lipgloss.JoinVertical(lipgloss.Left, result, "", notice)
+	return lipgloss.JoinVertical(lipgloss.Left, cards...)
 }
 
-// RenderNetworkTestWithLipgloss 使用 lipgloss 渲染网络测试结果
-func RenderNetworkTestWithLipgloss(results []network.SiteTestResult) string {
-	// 按名称排序结果
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Name < results[j].Name
-	})
+// conflictFieldLabels 冲突字段的显示名称
+var conflictFieldLabels = map[string]string{
+	"country_code": "国家/地区",
+	"region":       "省/州",
+	"city":         "城市",
+	"asn":          "AS号",
+	"timezone":     "时区",
+}
+
+// renderConflictCard 渲染各提供者结果不一致的字段及每个提供者返回的值
+func renderConflictCard(conflicts map[string]map[string]string) string {
+	fields := make([]string, 0, len(conflicts))
+	for field := range conflicts {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
 
-	// 计算可访问站点数量和平均值
-	accessibleCount := 0
-	totalSites := len(results)
-	var totalRespTime, totalPingTime, totalGenerate204 time.Duration
-	respTimeCount, pingTimeCount, generate204Count := 0, 0, 0
+	var lines []string
+	for _, field := range fields {
+		label := conflictFieldLabels[field]
+		if label == "" {
+			label = field
+		}
+		lines = append(lines, fmt.Sprintf("%s %s:", labelStyle.Render(IconWarning), warnStatusStyle.Render(label)))
 
-	for _, result := range results {
-		if result.Accessible {
-			accessibleCount++
-			totalRespTime += result.ResponseTime
-			respTimeCount++
+		providers := make([]string, 0, len(conflicts[field]))
+		for provider := range conflicts[field] {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+		for _, provider := range providers {
+			lines = append(lines, fmt.Sprintf("    %s: %s", labelStyle.Render(provider), valueStyle.Render(conflicts[field][provider])))
+		}
+	}
+	return DrawLipglossCard("数据冲突", IconWarning, lipgloss.JoinVertical(lipgloss.Left, lines...), warnColor)
+}
+
+// privacyFlags 返回提供者检测到的隐私标记，例如 "VPN, Tor (NordVPN)"，未检测到时返回空
+func privacyFlags(ipInfo *model.IPInfo) string {
+	var flags []string
+	if ipInfo.PrivacyVPN {
+		flags = append(flags, "VPN")
+	}
+	if ipInfo.PrivacyProxy {
+		flags = append(flags, "Proxy")
+	}
+	if ipInfo.PrivacyTor {
+		flags = append(flags, "Tor")
+	}
+	if ipInfo.PrivacyRelay {
+		flags = append(flags, "Relay")
+	}
+	if ipInfo.PrivacyHosting {
+		flags = append(flags, "Hosting")
+	}
+	if len(flags) == 0 {
+		return ""
+	}
+	result := strings.Join(flags, ", ")
+	if ipInfo.PrivacyService != "" {
+		result += " (" + ipInfo.PrivacyService + ")"
+	}
+	return result
+}
+
+// RenderLookupWithLipgloss 使用 lipgloss 渲染单个查询目标的IP信息
+func RenderLookupWithLipgloss(entry LookupEntry) string {
+	targetInfo := []string{
+		fmt.Sprintf("%s 查询目标:  %s", labelStyle.Render(IconLocation), accentValueStyle.Render(entry.Target)),
+	}
+	if entry.IP != "" && entry.IP != entry.Target {
+		targetInfo = append(targetInfo,
+			fmt.Sprintf("%s 解析地址:  %s", labelStyle.Render(IconNetwork), valueStyle.Render(entry.IP)))
+	}
+	if entry.Info != nil && entry.Info.Version != "" {
+		targetInfo = append(targetInfo,
+			fmt.Sprintf("%s IP版本:   %s", labelStyle.Render(IconInfo), valueStyle.Render(entry.Info.Version)))
+	}
+	targetCard := DrawLipglossCard("查询目标", IconInfo, lipgloss.JoinVertical(lipgloss.Left, targetInfo...), primaryColor)
+
+	if entry.Info == nil {
+		message := "无法获取IP详细信息"
+		if entry.Error != "" {
+			message += ": " + entry.Error
+		}
+		errorCard := DrawLipglossCard("错误", IconWarning, errorStatusStyle.Render(message), errorColor)
+		return lipgloss.JoinVertical(lipgloss.Left, targetCard, errorCard)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, targetCard, "", renderIPDetailCards(entry.Info))
+}
+
+// RenderLookupTableWithLipgloss 使用 lipgloss 以表格形式渲染多个查询目标的结果
+func RenderLookupTableWithLipgloss(entries []LookupEntry) string {
+	headers := []string{"目标", "IP", "国家", "城市", "ASN", "IP类型", "纯净度"}
+	colWidths := []int{18, 18, 6, 12, 10, 12, 8}
+
+	tableStyle := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#5FD7FF")).
+		Padding(0, 1)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+
+	// renderRow 按列宽渲染一行，超出列宽的内容会被截断
+	renderRow := func(cells []string) string {
+		var row strings.Builder
+		for i, cell := range cells {
+			row.WriteString(lipgloss.NewStyle().Width(colWidths[i]).MaxWidth(colWidths[i]).MaxHeight(1).Render(cell))
+		}
+		return row.String()
+	}
+
+	headerCells := make([]string, len(headers))
+	for i, h := range headers {
+		headerCells[i] = headerStyle.Render(h)
+	}
+	lines := []string{
+		titleStyle.Render(IconGlobe + " IP查询结果"),
+		renderRow(headerCells),
+	}
+
+	failed := 0
+	for _, entry := range entries {
+		if entry.Info == nil {
+			failed++
+			// 失败行的错误提示跨越剩余的列
+			lines = append(lines, renderRow([]string{
+				lipgloss.NewStyle().Bold(true).Render(entry.Target),
+				valueStyle.Render(entry.IP),
+			})+errorStatusStyle.Render(IconCross+" 查询失败"))
+			continue
+		}
+
+		info := entry.Info
+		var pureStyle lipgloss.Style
+		if info.PureScore >= 90 {
+			pureStyle = goodStatusStyle
+		} else if info.PureScore >= 70 {
+			pureStyle = warnStatusStyle
+		} else {
+			pureStyle = errorStatusStyle
 		}
 
-		if result.PingTime > 0 {
-			totalPingTime += result.PingTime
-			pingTimeCount++
+		lines = append(lines, renderRow([]string{
+			lipgloss.NewStyle().Bold(true).Render(entry.Target),
+			valueStyle.Render(entry.IP),
+			valueStyle.Render(info.CountryCode),
+			valueStyle.Render(info.City),
+			valueStyle.Render(info.ASN),
+			valueStyle.Render(info.IPType),
+			pureStyle.Render(fmt.Sprintf("%d分", info.PureScore)),
+		}))
+	}
+
+	lines = append(lines, lipgloss.NewStyle().
+		Faint(true).
+		Italic(true).
+		Render(fmt.Sprintf("共 %d 个地址，%d 个查询失败", len(entries), failed)))
+
+	return tableStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// RenderProviderStatusWithLipgloss 使用 lipgloss 渲染IP信息提供者的健康状态表格
+func RenderProviderStatusWithLipgloss(providers []ProviderStatus) string {
+	headers := []string{"提供者", "状态", "成功", "失败", "成功率", "平均延迟"}
+	colWidths := []int{18, 12, 8, 8, 8, 10}
+
+	tableStyle := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#5FD7FF")).
+		Padding(0, 1)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+
+	// renderRow 按列宽渲染一行，超出列宽的内容会被截断
+	renderRow := func(cells []string) string {
+		var row strings.Builder
+		for i, cell := range cells {
+			row.WriteString(lipgloss.NewStyle().Width(colWidths[i]).MaxWidth(colWidths[i]).MaxHeight(1).Render(cell))
+		}
+		return row.String()
+	}
+
+	headerCells := make([]string, len(headers))
+	for i, h := range headers {
+		headerCells[i] = headerStyle.Render(h)
+	}
+	lines := []string{
+		titleStyle.Render(IconServer + " 提供者健康状态"),
+		renderRow(headerCells),
+	}
+
+	var notes []string
+	for _, p := range providers {
+		var state string
+		switch p.State {
+		case "open":
+			state = errorStatusStyle.Render(IconCross + " 熔断")
+			notes = append(notes, fmt.Sprintf("%s 熔断至 %s: %s",
+				p.Name, p.OpenUntil.Format("15:04:05"), p.LastError))
+		case "half-open":
+			state = warnStatusStyle.Render(IconWarning + " 探测")
+		default:
+			state = goodStatusStyle.Render(IconCheck + " 正常")
 		}
 
-		if result.Generate204 > 0 {
-			totalGenerate204 += result.Generate204
-			generate204Count++
+		rate, latency := "-", "-"
+		if p.Successes+p.Failures > 0 {
+			rate = fmt.Sprintf("%.0f%%", p.SuccessRate*100)
 		}
+		if p.AvgLatencyMs > 0 {
+			latency = fmt.Sprintf("%.0fms", p.AvgLatencyMs)
+		}
+
+		lines = append(lines, renderRow([]string{
+			lipgloss.NewStyle().Bold(true).Render(p.Name),
+			state,
+			valueStyle.Render(fmt.Sprintf("%d", p.Successes)),
+			valueStyle.Render(fmt.Sprintf("%d", p.Failures)),
+			valueStyle.Render(rate),
+			valueStyle.Render(latency),
+		}))
 	}
 
-	// 计算平均值
-	var avgRespTime, avgPingTime, avgGenerate204 float64
-	if respTimeCount > 0 {
-		avgRespTime = totalRespTime.Seconds() / float64(respTimeCount)
+	// 熔断原因可能很长，截断到表格宽度
+	for _, note := range notes {
+		lines = append(lines, errorStatusStyle.Copy().MaxWidth(64).Render(note))
+	}
+	lines = append(lines, lipgloss.NewStyle().
+		Faint(true).
+		Italic(true).
+		Render(fmt.Sprintf("共 %d 个提供者，未指定 --provider 时按成功率和延迟加权随机选择", len(providers))))
+
+	return tableStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// RenderInterfacesWithLipgloss 使用 lipgloss 渲染网络接口列表
+func RenderInterfacesWithLipgloss(ifaces []network.InterfaceInfo) string {
+	if len(ifaces) == 0 {
+		return DrawLipglossCard("网络接口", IconComputer,
+			warnStatusStyle.Render("未发现任何网络接口"), primaryColor)
+	}
+
+	blocks := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		// 接口名称和默认路由标记
+		header := accentValueStyle.Render(iface.Name)
+		if iface.DefaultIPv4 {
+			header += " " + goodStatusStyle.Render("[IPv4默认路由 "+IconArrowRight+" "+gatewayText(iface.GatewayIPv4)+"]")
+		}
+		if iface.DefaultIPv6 {
+			header += " " + goodStatusStyle.Render("[IPv6默认路由 "+IconArrowRight+" "+gatewayText(iface.GatewayIPv6)+"]")
+		}
+
+		status := goodStatusStyle.Render("启用")
+		if !iface.Up {
+			status = errorStatusStyle.Render("停用")
+		}
+		mac := iface.HardwareAddr
+		if mac == "" {
+			mac = "-"
+		}
+
+		lines := []string{
+			header,
+			fmt.Sprintf("  %s %s  %s %s  %s %s",
+				labelStyle.Render("状态:"), status,
+				labelStyle.Render("MTU:"), valueStyle.Render(fmt.Sprintf("%d", iface.MTU)),
+				labelStyle.Render("MAC:"), valueStyle.Render(mac)),
+			fmt.Sprintf("  %s %s", labelStyle.Render("标志:"), valueStyle.Render(strings.Join(iface.Flags, "|"))),
+		}
+
+		if len(iface.Addrs) == 0 {
+			lines = append(lines, fmt.Sprintf("  %s %s", labelStyle.Render("地址:"), renderAddress("", valueStyle)))
+		}
+		for i, addr := range iface.Addrs {
+			label := "     "
+			if i == 0 {
+				label = "地址:"
+			}
+			lines = append(lines, fmt.Sprintf("  %s %s", labelStyle.Render(label), valueStyle.Render(addr.String())))
+		}
+
+		blocks = append(blocks, lipgloss.JoinVertical(lipgloss.Left, lines...))
 	}
-	if pingTimeCount > 0 {
-		avgPingTime = float64(totalPingTime) / float64(pingTimeCount) / float64(time.Millisecond)
+
+	content := strings.Join(blocks, "\n\n")
+	return DrawLipglossCard("网络接口", IconComputer, content, primaryColor)
+}
+
+// gatewayText 返回网关的显示文本，直连路由没有网关
+func gatewayText(gateway string) string {
+	if gateway == "" {
+		return "直连"
 	}
-	if generate204Count > 0 {
-		avgGenerate204 = float64(totalGenerate204) / float64(generate204Count) / float64(time.Millisecond)
+	return gateway
+}
+
+// renderAddress 渲染IP地址，地址为空时显示为灰色的"无"
+func renderAddress(addr string, style lipgloss.Style) string {
+	if addr == "" {
+		return lipgloss.NewStyle().Foreground(grayColor).Render("无")
 	}
+	return style.Render(addr)
+}
+
+// RenderNetworkTestWithLipgloss 使用 lipgloss 渲染网络测试结果
+func RenderNetworkTestWithLipgloss(results []network.SiteTestResult) string {
+	// 按名称排序结果
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	// 计算可访问站点数量和各项延迟的中位数，避免个别慢站点影响整体结果
+	summary := network.Summarize(results)
+	accessibleCount := summary.AccessibleSites
+	totalSites := summary.TotalSites
+	medRespTime := summary.MedianResponseTime.Seconds()
+	medPingTime := float64(summary.MedianPingTime) / float64(time.Millisecond)
+	medGenerate204 := float64(summary.MedianGenerate204) / float64(time.Millisecond)
 
 	// 计算可访问率
-	accessRate := float64(accessibleCount) / float64(totalSites) * 100
-	var accessRateColor, avgRespTimeColor, avgPingTimeColor, avgGenerate204Color lipgloss.Style
+	accessRate := summary.AccessRate
+	var accessRateColor, medRespTimeColor, medPingTimeColor, medGenerate204Color lipgloss.Style
 	
 	if accessRate >= 90 {
 		accessRateColor = goodStatusStyle
@@ -277,32 +593,32 @@ func RenderNetworkTestWithLipgloss(results []network.SiteTestResult) string {
 		accessRateColor = errorStatusStyle
 	}
 
-	if avgRespTime < 0.3 {
-		avgRespTimeColor = goodStatusStyle
-	} else if avgRespTime < 1.0 {
-		avgRespTimeColor = warnStatusStyle
+	if medRespTime < 0.3 {
+		medRespTimeColor = goodStatusStyle
+	} else if medRespTime < 1.0 {
+		medRespTimeColor = warnStatusStyle
 	} else {
-		avgRespTimeColor = errorStatusStyle
+		medRespTimeColor = errorStatusStyle
 	}
 
-	if avgPingTime < 50 {
-		avgPingTimeColor = goodStatusStyle
-	} else if avgPingTime < 150 {
-		avgPingTimeColor = warnStatusStyle
+	if medPingTime < 50 {
+		medPingTimeColor = goodStatusStyle
+	} else if medPingTime < 150 {
+		medPingTimeColor = warnStatusStyle
 	} else {
-		avgPingTimeColor = errorStatusStyle
+		medPingTimeColor = errorStatusStyle
 	}
 
-	if avgGenerate204 < 200 {
-		avgGenerate204Color = goodStatusStyle
-	} else if avgGenerate204 < 500 {
-		avgGenerate204Color = warnStatusStyle
+	if medGenerate204 < 200 {
+		medGenerate204Color = goodStatusStyle
+	} else if medGenerate204 < 500 {
+		medGenerate204Color = warnStatusStyle
 	} else {
-		avgGenerate204Color = errorStatusStyle
+		medGenerate204Color = errorStatusStyle
 	}
 
 	// 获取网络评级
-	networkRating := getNetworkRating(accessRate, avgRespTime, avgPingTime)
+	networkRating := summary.Rating()
 	
 	// 构建统计信息
 	statsInfo := lipgloss.JoinVertical(
@@ -314,18 +630,18 @@ func RenderNetworkTestWithLipgloss(results []network.SiteTestResult) string {
 		"",
 		lipgloss.JoinHorizontal(
 			lipgloss.Left,
-			fmt.Sprintf("%s平均响应时间: %s   ",
+			fmt.Sprintf("%s响应时间中位数: %s   ",
 				labelStyle.Render(), 
-				avgRespTimeColor.Render(fmt.Sprintf("%.2f秒", avgRespTime))),
-			fmt.Sprintf("%sPing平均延迟: %s",
+				medRespTimeColor.Render(fmt.Sprintf("%.2f秒", medRespTime))),
+			fmt.Sprintf("%sPing延迟中位数: %s",
 				labelStyle.Render(), 
-				avgPingTimeColor.Render(fmt.Sprintf("%.1fms", avgPingTime))),
+				medPingTimeColor.Render(fmt.Sprintf("%.1fms", medPingTime))),
 		),
 		lipgloss.JoinHorizontal(
 			lipgloss.Left,
-			fmt.Sprintf("%s204测试延迟: %s   ",
+			fmt.Sprintf("%s204延迟中位数: %s   ",
 				labelStyle.Render(), 
-				avgGenerate204Color.Render(fmt.Sprintf("%.1fms", avgGenerate204))),
+				medGenerate204Color.Render(fmt.Sprintf("%.1fms", medGenerate204))),
 			fmt.Sprintf("%s总体评分: %s",
 				labelStyle.Render(), 
 				networkRating),
@@ -374,7 +690,7 @@ func RenderNetworkTestWithLipgloss(results []network.SiteTestResult) string {
 			lipgloss.NewStyle().Bold(true).Render(result.Name),
 			getFriendlyStatusTextLipgloss(result.Accessible),
 			getFriendlyResponseTimeTextLipgloss(result.ResponseTime, result.Accessible),
-			getFriendlyPingTextLipgloss(result.PingTime, result.PingLoss),
+			getFriendlyPingTextLipgloss(result.PingTime, result.PingLoss) + getPingMethodTagLipgloss(result.PingMethod),
 			getFriendlyLossRateTextLipgloss(result.PingLoss),
 			getFriendlyGenerateTextLipgloss(result.Generate204),
 		})
@@ -401,7 +717,7 @@ func RenderNetworkTestWithLipgloss(results []network.SiteTestResult) string {
 	noteText := lipgloss.NewStyle().
 		Faint(true).
 		Italic(true).
-		Render("注: 绿色=良好, 黄色=中等, 红色=较差")
+		Render("注: 绿色=良好, 黄色=中等, 红色=较差; tcp=ICMP被屏蔽，延迟为TCP握手时间")
 	
 	tableContent = lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -491,6 +807,14 @@ func getFriendlyPingTextLipgloss(pingTime time.Duration, pingLoss float64) strin
 	return pingStyle.Render(fmt.Sprintf("%.1fms", pingMs))
 }
 
+// getPingMethodTagLipgloss 延迟由TCP握手测得时在延迟后标注
+func getPingMethodTagLipgloss(method string) string {
+	if method != network.PingMethodTCP {
+		return ""
+	}
+	return lipgloss.NewStyle().Faint(true).Render(" tcp")
+}
+
 func getFriendlyLossRateTextLipgloss(lossRate float64) string {
 	lossPercent := lossRate * 100
 	
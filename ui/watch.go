@@ -0,0 +1,427 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cikichen/MyIp/network"
+)
+
+// WatchOptions 持续监控模式的参数
+type WatchOptions struct {
+	Interval time.Duration                                      // 两轮探测之间的间隔
+	History  int                                                // 每个站点保留的历史样本数，用于迷你图和滚动丢包率
+	Probe    func(ctx context.Context) []network.SiteTestResult // 执行一轮探测
+	Detail   func(result network.SiteTestResult) string         // 渲染单个站点的详细信息卡片
+}
+
+// 排序方式
+const (
+	watchSortName = iota
+	watchSortLatency
+	watchSortLoss
+	watchSortStatus
+	watchSortCount
+)
+
+// watchSortNames 排序方式的名称
+var watchSortNames = []string{"名称", "延迟", "丢包率", "状态"}
+
+// sparkLevels 迷你图使用的字符，从低到高
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// watchTickMsg 到达下一轮探测的时间，id 用于丢弃被手动探测取代的定时
+type watchTickMsg struct{ id int }
+
+// watchResultMsg 一轮探测的结果
+type watchResultMsg []network.SiteTestResult
+
+// siteHistory 单个站点的历史探测结果
+type siteHistory struct {
+	latest  network.SiteTestResult
+	samples []time.Duration // 最近的响应时间，不可访问时为0
+	losses  []float64       // 最近的Ping丢包率
+}
+
+// watchModel 持续监控的 bubbletea 模型
+type watchModel struct {
+	opts   WatchOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	names []string // 按首次出现顺序记录的站点名称
+	sites map[string]*siteHistory
+
+	sortBy     int
+	cursor     int
+	detail     bool // 是否显示选中站点的详细信息
+	paused     bool
+	probing    bool
+	due        bool // 暂停期间到达了探测时间，恢复后立即探测
+	tickID     int
+	rounds     int
+	lastUpdate time.Time
+}
+
+// RunWatch 以全屏界面持续探测站点并实时刷新结果，直到用户退出
+func RunWatch(opts WatchOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.History <= 0 {
+		opts.History = 30
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := &watchModel{
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		sites:  make(map[string]*siteHistory),
+	}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m *watchModel) Init() tea.Cmd {
+	return m.probe()
+}
+
+// probe 开始一轮探测
+func (m *watchModel) probe() tea.Cmd {
+	m.probing = true
+	m.due = false
+	m.tickID++
+	ctx, probe := m.ctx, m.opts.Probe
+	return func() tea.Msg {
+		return watchResultMsg(probe(ctx))
+	}
+}
+
+// tick 在间隔之后触发下一轮探测
+func (m *watchModel) tick() tea.Cmd {
+	id := m.tickID
+	return tea.Tick(m.opts.Interval, func(time.Time) tea.Msg {
+		return watchTickMsg{id: id}
+	})
+}
+
+func (m *watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case watchResultMsg:
+		m.probing = false
+		if m.ctx.Err() != nil {
+			return m, nil
+		}
+		m.record(msg)
+		return m, m.tick()
+	case watchTickMsg:
+		if msg.id != m.tickID || m.probing {
+			return m, nil
+		}
+		if m.paused {
+			m.due = true
+			return m, nil
+		}
+		return m, m.probe()
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// handleKey 处理按键
+func (m *watchModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		m.cancel()
+		return m, tea.Quit
+	case "esc", "backspace":
+		m.detail = false
+	case "enter":
+		m.detail = !m.detail && len(m.names) > 0
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.names)-1 {
+			m.cursor++
+		}
+	case "s":
+		// 切换排序时保持选中同一个站点
+		selected := m.selected()
+		m.sortBy = (m.sortBy + 1) % watchSortCount
+		for i, name := range m.sorted() {
+			if selected != nil && name == selected.latest.Name {
+				m.cursor = i
+			}
+		}
+	case "p", " ":
+		m.paused = !m.paused
+		if !m.paused && m.due && !m.probing {
+			return m, m.probe()
+		}
+	case "r":
+		if !m.probing {
+			return m, m.probe()
+		}
+	}
+	return m, nil
+}
+
+// record 记录一轮探测结果，只保留最近 History 个样本
+func (m *watchModel) record(results []network.SiteTestResult) {
+	m.rounds++
+	m.lastUpdate = time.Now()
+	for _, result := range results {
+		h, ok := m.sites[result.Name]
+		if !ok {
+			h = &siteHistory{}
+			m.sites[result.Name] = h
+			m.names = append(m.names, result.Name)
+		}
+		h.latest = result
+
+		sample := result.ResponseTime
+		if !result.Accessible {
+			sample = 0
+		}
+		h.samples = appendLimited(h.samples, sample, m.opts.History)
+		h.losses = appendLimitedFloat(h.losses, result.PingLoss, m.opts.History)
+	}
+}
+
+// appendLimited 追加样本并只保留最后 limit 个
+func appendLimited(samples []time.Duration, v time.Duration, limit int) []time.Duration {
+	samples = append(samples, v)
+	if len(samples) > limit {
+		samples = samples[len(samples)-limit:]
+	}
+	return samples
+}
+
+// appendLimitedFloat 追加样本并只保留最后 limit 个
+func appendLimitedFloat(samples []float64, v float64, limit int) []float64 {
+	samples = append(samples, v)
+	if len(samples) > limit {
+		samples = samples[len(samples)-limit:]
+	}
+	return samples
+}
+
+// rollingLoss 历史样本的平均Ping丢包率
+func (h *siteHistory) rollingLoss() float64 {
+	if len(h.losses) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, loss := range h.losses {
+		sum += loss
+	}
+	return sum / float64(len(h.losses))
+}
+
+// failures 历史样本中不可访问的次数
+func (h *siteHistory) failures() int {
+	n := 0
+	for _, sample := range h.samples {
+		if sample == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// sorted 返回按当前排序方式排列的站点名称
+func (m *watchModel) sorted() []string {
+	names := append([]string(nil), m.names...)
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := m.sites[names[i]], m.sites[names[j]]
+		switch m.sortBy {
+		case watchSortLatency:
+			// 不可访问的站点排在最后
+			if a.latest.Accessible != b.latest.Accessible {
+				return a.latest.Accessible
+			}
+			if a.latest.ResponseTime != b.latest.ResponseTime {
+				return a.latest.ResponseTime < b.latest.ResponseTime
+			}
+		case watchSortLoss:
+			if la, lb := a.rollingLoss(), b.rollingLoss(); la != lb {
+				return la > lb
+			}
+		case watchSortStatus:
+			// 不可访问的站点排在前面，便于发现问题
+			if a.latest.Accessible != b.latest.Accessible {
+				return !a.latest.Accessible
+			}
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// selected 返回光标所在的站点
+func (m *watchModel) selected() *siteHistory {
+	names := m.sorted()
+	if m.cursor < 0 || m.cursor >= len(names) {
+		return nil
+	}
+	return m.sites[names[m.cursor]]
+}
+
+func (m *watchModel) View() string {
+	if m.detail {
+		if h := m.selected(); h != nil {
+			return m.renderDetail(h)
+		}
+	}
+	return m.renderTable()
+}
+
+// renderStatusLine 渲染探测轮次、更新时间和运行状态
+func (m *watchModel) renderStatusLine() string {
+	parts := []string{fmt.Sprintf("每%s探测一次", m.opts.Interval)}
+	if m.rounds > 0 {
+		parts = append(parts, fmt.Sprintf("第%d轮", m.rounds), "更新于 "+m.lastUpdate.Format("15:04:05"))
+	}
+	status := strings.Join(parts, " · ")
+	switch {
+	case m.probing:
+		status += "  " + warnStatusStyle.Render("探测中...")
+	case m.paused:
+		status += "  " + errorStatusStyle.Render("已暂停")
+	}
+	return lipgloss.NewStyle().Faint(true).Render(status)
+}
+
+// renderTable 渲染所有站点的监控表格
+func (m *watchModel) renderTable() string {
+	headers := []string{"", "站点", "状态", "HTTP响应", "延迟趋势", "Ping延迟", "滚动丢包率", "失败次数"}
+	colWidths := []int{2, 12, 12, 12, m.opts.History + 2, 15, 12, 10}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	var headerRow strings.Builder
+	for i, h := range headers {
+		headerRow.WriteString(lipgloss.NewStyle().Width(colWidths[i]).Render(headerStyle.Render(h)))
+	}
+
+	lines := []string{
+		titleStyle.Render("📡 站点连通性监控"),
+		m.renderStatusLine(),
+		"",
+		headerRow.String(),
+	}
+
+	if len(m.names) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("等待第一轮探测结果..."))
+	}
+	for i, name := range m.sorted() {
+		h := m.sites[name]
+		cursor := " "
+		nameStyle := lipgloss.NewStyle().Bold(true)
+		if i == m.cursor {
+			cursor = "›"
+			nameStyle = nameStyle.Reverse(true)
+		}
+		cells := []string{
+			cursor,
+			nameStyle.Render(name),
+			getFriendlyStatusTextLipgloss(h.latest.Accessible),
+			getFriendlyResponseTimeTextLipgloss(h.latest.ResponseTime, h.latest.Accessible),
+			sparkline(h.samples, m.opts.History),
+			getFriendlyPingTextLipgloss(h.latest.PingTime, h.latest.PingLoss) + getPingMethodTagLipgloss(h.latest.PingMethod),
+			getFriendlyLossRateTextLipgloss(h.rollingLoss()),
+			fmt.Sprintf("%d/%d", h.failures(), len(h.samples)),
+		}
+		var row strings.Builder
+		for j, cell := range cells {
+			row.WriteString(lipgloss.NewStyle().Width(colWidths[j]).Render(cell))
+		}
+		lines = append(lines, row.String())
+	}
+
+	lines = append(lines, "", renderWatchHelp(
+		"↑/↓ 选择",
+		"enter 详情",
+		"s 排序("+watchSortNames[m.sortBy]+")",
+		"p 暂停/继续",
+		"r 立即探测",
+		"q 退出",
+	))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// renderDetail 渲染选中站点的详细信息和历史趋势
+func (m *watchModel) renderDetail(h *siteHistory) string {
+	var detail string
+	if m.opts.Detail != nil {
+		detail = m.opts.Detail(h.latest)
+	}
+	history := fmt.Sprintf("延迟趋势: %s\n滚动丢包率: %s   失败次数: %d/%d",
+		sparkline(h.samples, len(h.samples)),
+		getFriendlyLossRateTextLipgloss(h.rollingLoss()),
+		h.failures(), len(h.samples))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("📡 "+h.latest.Name),
+		m.renderStatusLine(),
+		"",
+		history,
+		"",
+		detail,
+		renderWatchHelp("esc 返回", "p 暂停/继续", "r 立即探测", "q 退出"),
+	)
+}
+
+// renderWatchHelp 渲染按键说明
+func renderWatchHelp(keys ...string) string {
+	return lipgloss.NewStyle().Faint(true).Render(strings.Join(keys, "  "))
+}
+
+// sparkline 将响应时间样本渲染为迷你图，不可访问的样本显示为红色的"×"
+func sparkline(samples []time.Duration, width int) string {
+	var lo, hi time.Duration
+	for _, s := range samples {
+		if s <= 0 {
+			continue
+		}
+		if lo == 0 || s < lo {
+			lo = s
+		}
+		if s > hi {
+			hi = s
+		}
+	}
+
+	var sb strings.Builder
+	if pad := width - len(samples); pad > 0 {
+		sb.WriteString(strings.Repeat(" ", pad))
+	}
+	for _, s := range samples {
+		if s <= 0 {
+			sb.WriteString(errorStatusStyle.Render("×"))
+			continue
+		}
+		// 高度按窗口内的最小和最大值缩放，颜色与响应时间的阈值一致
+		level := len(sparkLevels) / 2
+		if hi > lo {
+			level = int(float64(s-lo) / float64(hi-lo) * float64(len(sparkLevels)-1))
+		}
+		style := goodStatusStyle
+		if s >= 1000*time.Millisecond {
+			style = errorStatusStyle
+		} else if s >= 300*time.Millisecond {
+			style = warnStatusStyle
+		}
+		sb.WriteString(style.Render(string(sparkLevels[level])))
+	}
+	return sb.String()
+}